@@ -1,27 +1,249 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"slices"
-	"sort"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/moderation"
 	"github.com/google/uuid"
 )
 
-const censor = "****"
 const maxChirpLength = 140
 
+// Chirp is a chirp as the API returns it. ReplyCount, RechirpCount and
+// QuoteCount count every reply, rechirp and quote, including ones the viewer
+// is not allowed to see, such as followers-only or private replies. They
+// only say how many exist, never who wrote them or what they say.
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	Body      string    `json:"body"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Body       string     `json:"body"`
+	Edited     bool       `json:"edited"`
+	InReplyTo  *uuid.UUID `json:"in_reply_to,omitempty"`
+	ReplyCount int32      `json:"reply_count"`
+	Deleted    bool       `json:"deleted,omitempty"`
+	LikeCount  int32      `json:"like_count"`
+	LikedByMe  *bool      `json:"liked_by_me,omitempty"`
+	Bookmarked *bool      `json:"bookmarked,omitempty"`
+	Visibility string     `json:"visibility"`
+	Pinned     bool       `json:"pinned,omitempty"`
+
+	Mentions []ChirpMention `json:"mentions"`
+	Media    []Media        `json:"media"`
+	Poll     *Poll          `json:"poll,omitempty"`
+
+	RechirpOf    *uuid.UUID `json:"rechirp_of,omitempty"`
+	QuoteOf      *uuid.UUID `json:"quote_of,omitempty"`
+	RechirpCount int32      `json:"rechirp_count"`
+	QuoteCount   int32      `json:"quote_count"`
+	Rechirped    *Chirp     `json:"rechirped_chirp,omitempty"`
+	Quoted       *Chirp     `json:"quoted_chirp,omitempty"`
+}
+
+func chirpFromDB(chirp database.Chirp) Chirp {
+	resp := Chirp{
+		ID:         chirp.ID,
+		CreatedAt:  chirp.CreatedAt,
+		UpdatedAt:  chirp.UpdatedAt,
+		Body:       chirp.Body,
+		UserID:     chirp.UserID,
+		Edited:     chirp.UpdatedAt.After(chirp.CreatedAt),
+		ReplyCount: chirp.ReplyCount,
+		Deleted:    chirp.TombstonedAt.Valid,
+		LikeCount:  chirp.LikeCount,
+		Visibility: chirp.Visibility,
+
+		Mentions: []ChirpMention{},
+		Media:    []Media{},
+
+		RechirpCount: chirp.RechirpCount,
+		QuoteCount:   chirp.QuoteCount,
+	}
+	if chirp.InReplyTo.Valid {
+		resp.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	if chirp.RechirpOf.Valid {
+		resp.RechirpOf = &chirp.RechirpOf.UUID
+	}
+	if chirp.QuoteOf.Valid {
+		resp.QuoteOf = &chirp.QuoteOf.UUID
+	}
+	return resp
+}
+
+var errChirpTooLong = errors.New("Chirp is too long")
+var errReplyTargetNotFound = errors.New("failed to find chirp to reply to")
+var errQuoteTargetNotFound = errors.New("failed to find chirp to quote")
+var errQuoteNeedsBody = errors.New("a quote chirp needs a body")
+
+// checkChirpBody enforces the length limit and runs the body through the
+// moderation rules. The result's Text is the body as it should be stored.
+func (cfg *apiConfig) checkChirpBody(ctx context.Context, body string) (moderation.Result, error) {
+	if len(body) > maxChirpLength {
+		return moderation.Result{}, errChirpTooLong
+	}
+	return cfg.moderateBody(ctx, body)
+}
+
+// moderateBody runs body through the moderation rules without the length
+// limit.
+func (cfg *apiConfig) moderateBody(ctx context.Context, body string) (moderation.Result, error) {
+	filter, err := cfg.moderationFilter(ctx)
+	if err != nil {
+		return moderation.Result{}, err
+	}
+	return moderation.Check(body, filter), nil
+}
+
+// chirpRemoved reports whether a chirp has been tombstoned or moved to its
+// owner's trash. Either way it can no longer be read, edited or replied to.
+func chirpRemoved(chirp database.Chirp) bool {
+	return chirp.TombstonedAt.Valid || chirp.DeletedAt.Valid
+}
+
+type newChirp struct {
+	Body       string
+	UserID     uuid.UUID
+	InReplyTo  uuid.NullUUID
+	QuoteOf    uuid.NullUUID
+	MediaIDs   []uuid.UUID
+	Visibility string
+	Poll       *newPoll
+}
+
+// checkChirpReferences checks that the chirp a new chirp replies to is
+// visible to its author, that the chirp it quotes exists, and that its media
+// are the author's own unattached uploads. The returned params quote the
+// original chirp if QuoteOf named a rechirp.
+func checkChirpReferences(ctx context.Context, q *database.Queries, params newChirp) (newChirp, error) {
+	if params.InReplyTo.Valid {
+		visible, err := visibleChirpIDs(ctx, q, uuid.NullUUID{UUID: params.UserID, Valid: true}, []uuid.UUID{params.InReplyTo.UUID})
+		if err != nil {
+			return params, err
+		}
+		if !visible[params.InReplyTo.UUID] {
+			return params, errReplyTargetNotFound
+		}
+	}
+
+	if params.QuoteOf.Valid {
+		if params.Body == "" {
+			return params, errQuoteNeedsBody
+		}
+		quoted, err := resolveRechirpTarget(ctx, q, params.QuoteOf.UUID)
+		if err != nil {
+			return params, errQuoteTargetNotFound
+		}
+		params.QuoteOf.UUID = quoted.ID
+	}
+
+	if len(params.MediaIDs) > 0 {
+		attachable, err := q.CountAttachableMedia(ctx, database.CountAttachableMediaParams{
+			MediaIds: params.MediaIDs,
+			UserID:   params.UserID,
+		})
+		if err != nil {
+			return params, err
+		}
+		if attachable != int64(len(params.MediaIDs)) {
+			return params, errInvalidMedia
+		}
+	}
+	return params, nil
+}
+
+// insertChirp writes a chirp along with the counters, hashtags and mentions
+// that go with it. The body must already have been through checkChirpBody.
+func insertChirp(ctx context.Context, qtx *database.Queries, params newChirp) (database.Chirp, error) {
+	params, err := checkChirpReferences(ctx, qtx, params)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if params.InReplyTo.Valid {
+		updated, err := qtx.IncrementReplyCount(ctx, params.InReplyTo.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if updated == 0 {
+			return database.Chirp{}, errReplyTargetNotFound
+		}
+	}
+
+	if params.QuoteOf.Valid {
+		err = qtx.IncrementQuoteCount(ctx, params.QuoteOf.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	chirp, err := qtx.CreateChirp(ctx, database.CreateChirpParams{
+		Body:       params.Body,
+		UserID:     params.UserID,
+		InReplyTo:  params.InReplyTo,
+		QuoteOf:    params.QuoteOf,
+		Visibility: params.Visibility,
+	})
+	if err != nil {
+		return chirp, err
+	}
+
+	if len(params.MediaIDs) > 0 {
+		attached, err := qtx.AttachMedia(ctx, database.AttachMediaParams{
+			ChirpID:  chirp.ID,
+			MediaIds: params.MediaIDs,
+			UserID:   params.UserID,
+		})
+		if isUniqueViolation(err) || (err == nil && attached != int64(len(params.MediaIDs))) {
+			return chirp, errInvalidMedia
+		}
+		if err != nil {
+			return chirp, err
+		}
+	}
+
+	if params.Poll != nil {
+		err = createPoll(ctx, qtx, chirp.ID, params.Poll)
+		if err != nil {
+			return chirp, err
+		}
+	}
+
+	err = tagChirp(ctx, qtx, chirp)
+	if err != nil {
+		return chirp, err
+	}
+	err = mentionUsers(ctx, qtx, chirp)
+	return chirp, err
+}
+
+// respondWithInsertError reports why insertChirp failed.
+func respondWithInsertError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errReplyTargetNotFound), errors.Is(err, errQuoteTargetNotFound):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errQuoteNeedsBody), errors.Is(err, errInvalidMedia):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Error creating chirp in database: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create chirp")
+	}
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
 }
 
 func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request) {
@@ -38,19 +260,34 @@ func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request
 	}
 
 	chirp, err := cfg.db.GetChirpById(r.Context(), chirpUuid)
-	if err != nil {
+	if err != nil || chirpRemoved(chirp) {
 		log.Printf("Error getting chirp: %s", err)
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	visible, err := chirpVisibleTo(r.Context(), cfg.db, cfg.viewerID(r), chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp visibility")
+		return
+	}
+	if !visible {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
 
-	respondWithJSON(w, 200, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.CreatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, 200, chirps[0])
+}
+
+type chirpPage struct {
+	Chirps []Chirp `json:"chirps"`
+	Next   string  `json:"next,omitempty"`
+	Prev   string  `json:"prev,omitempty"`
 }
 
 func (cfg *apiConfig) handlersGetChirps(w http.ResponseWriter, r *http.Request) {
@@ -58,59 +295,140 @@ func (cfg *apiConfig) handlersGetChirps(w http.ResponseWriter, r *http.Request)
 	if sorted == "" {
 		sorted = "asc"
 	}
+	if sorted != "asc" && sorted != "desc" {
+		respondWithError(w, http.StatusBadRequest, "sort must be asc or desc")
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	authorUUID := uuid.NullUUID{}
 	authorID := r.URL.Query().Get("author_id")
-	chirps := []Chirp{}
-	if authorID == "" {
-		allChirps, err := cfg.db.GetChirps(r.Context())
+	if authorID != "" {
+		userID, err := uuid.Parse(authorID)
 		if err != nil {
-			log.Printf("Error getting chirps in database: %s", err)
-			w.WriteHeader(500)
+			respondWithError(w, http.StatusInternalServerError, "failed to parse provided author id to UUID")
 			return
 		}
-		for _, dbChirp := range allChirps {
-			chirps = append(chirps, Chirp{
-				ID:        dbChirp.ID,
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.CreatedAt,
-				Body:      dbChirp.Body,
-				UserID:    dbChirp.UserID,
-			})
-		}
-	} else {
-		userID, err := uuid.Parse(authorID)
+		authorUUID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	includePinned := false
+	if r.URL.Query().Has("include_pinned") {
+		includePinned, err = strconv.ParseBool(r.URL.Query().Get("include_pinned"))
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "failed to parse provided author id to UUID")
+			respondWithError(w, http.StatusBadRequest, "include_pinned must be true or false")
 			return
 		}
-		authorChirps, err := cfg.db.GetChirpsByAuthor(r.Context(), userID)
+		if includePinned && !authorUUID.Valid {
+			respondWithError(w, http.StatusBadRequest, "include_pinned needs an author_id")
+			return
+		}
+	}
+
+	excludePinnedBy := uuid.NullUUID{}
+	if includePinned {
+		excludePinnedBy = authorUUID
+	}
+
+	// Paging backwards walks the listing in the opposite direction from the
+	// requested sort; buildPage flips the rows back afterwards.
+	fetch := func(page pageParams) ([]database.Chirp, error) {
+		params := database.GetChirpsPageDescParams{
+			AuthorID:        authorUUID,
+			ViewerID:        cfg.viewerID(r),
+			ExcludePinnedBy: excludePinnedBy,
+			Limit:           page.fetchLimit(),
+		}
+		if page.cursor != nil && !page.cursor.Start {
+			params.CursorCreatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+			params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+		}
+		if (sorted == "desc") != page.backward() {
+			return cfg.db.GetChirpsPageDesc(r.Context(), params)
+		}
+		return cfg.db.GetChirpsPageAsc(r.Context(), database.GetChirpsPageAscParams(params))
+	}
+
+	var dbChirps []database.Chirp
+	if page.cursor != nil {
+		dbChirps, err = fetch(page)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "failed to get chirps with author id")
+			log.Printf("Error getting chirps in database: %s", err)
+			w.WriteHeader(500)
 			return
 		}
-		for _, dbChirp := range authorChirps {
-			chirps = append(chirps, Chirp{
-				ID:        dbChirp.ID,
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.CreatedAt,
-				Body:      dbChirp.Body,
-				UserID:    dbChirp.UserID,
-			})
+		// Paging back onto the start of the listing serves the first page,
+		// so the pinned chirps come back with it.
+		if includePinned && page.backward() && len(dbChirps) <= page.limit {
+			page = pageParams{limit: page.limit}
 		}
 	}
-	if sorted == "desc" {
-		sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
+
+	// Pinned chirps lead the first page and count towards its size. They
+	// are left out of the paged listing on every page, so they never show
+	// up a second time further down.
+	pinned := []Chirp{}
+	if page.cursor == nil {
+		if includePinned {
+			pinned, err = cfg.pinnedChirps(r, authorUUID.UUID)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "failed to get pinned chirps")
+				return
+			}
+			pinned = pinned[:min(len(pinned), page.limit)]
+			page.limit -= len(pinned)
+		}
+		dbChirps, err = fetch(page)
+		if err != nil {
+			log.Printf("Error getting chirps in database: %s", err)
+			w.WriteHeader(500)
+			return
+		}
 	}
 
-	respondWithJSON(w, http.StatusOK, chirps)
+	fetched := len(dbChirps)
+	dbChirps, next, prev := buildPage(dbChirps, page, func(c database.Chirp) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+	if len(dbChirps) == 0 && fetched > 0 {
+		// The pinned chirps filled the first page, so the rest of the
+		// listing starts on the next one.
+		next = encodeCursor(pageCursor{Start: true})
+	}
 
-}
+	chirps, err := cfg.listedChirpsForViewer(r, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
 
-func (cfg *apiConfig) handlerChirps(w http.ResponseWriter, r *http.Request) {
+	if len(pinned) > 0 {
+		chirps = append(pinned, chirps...)
+	}
 
-	profaneWords := []string{"kerfuffle", "sharbert", "fornax"}
+	setLinkHeader(w, r, next, prev)
+	respondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps: chirps,
+		Next:   next,
+		Prev:   prev,
+	})
+}
 
+func (cfg *apiConfig) handlerChirps(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body       string      `json:"body"`
+		InReplyTo  *uuid.UUID  `json:"in_reply_to"`
+		RechirpOf  *uuid.UUID  `json:"rechirp_of"`
+		QuoteOf    *uuid.UUID  `json:"quote_of"`
+		MediaIDs   []uuid.UUID `json:"media_ids"`
+		PublishAt  *time.Time  `json:"publish_at"`
+		Visibility string      `json:"visibility"`
+		Poll       *pollParams `json:"poll"`
 	}
 
 	userID := r.Context().Value("userID").(uuid.UUID)
@@ -124,35 +442,111 @@ func (cfg *apiConfig) handlerChirps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//validate chirp
-	if len(params.Body) > maxChirpLength {
-		respondWithError(w, 400, "Chirp is too long")
+	if params.RechirpOf != nil {
+		if params.Body != "" || params.InReplyTo != nil || params.QuoteOf != nil || len(params.MediaIDs) > 0 || params.PublishAt != nil || params.Visibility != "" || params.Poll != nil {
+			respondWithError(w, 400, "a rechirp cannot have a body, reply, quote, media, schedule, visibility or poll")
+			return
+		}
+		cfg.createRechirp(w, r, userID, *params.RechirpOf)
+		return
+	}
+
+	mediaIDs, err := parseMediaIDs(params.MediaIDs)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
 
-	} else {
-		words := strings.Fields(params.Body)
-		for _, word := range words {
-			if slices.Contains(profaneWords, strings.ToLower(word)) {
-				params.Body = strings.Replace(params.Body, word, censor, -1)
-			}
+	// A poll closes at a fixed time, which a schedule could push past.
+	var poll *newPoll
+	if params.Poll != nil {
+		if params.PublishAt != nil {
+			respondWithError(w, 400, "a scheduled chirp cannot have a poll")
+			return
 		}
-		chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-			Body:   params.Body,
-			UserID: userID,
-		})
+		poll, err = parsePoll(*params.Poll)
 		if err != nil {
-			log.Printf("Error creating chirp in database: %s", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			respondWithError(w, 400, err.Error())
 			return
 		}
-		resp := Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
+	}
+
+	result, err := cfg.checkChirpBody(r.Context(), params.Body)
+	if errors.Is(err, errChirpTooLong) {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if err == nil && poll != nil {
+		result, err = cfg.moderatePoll(r.Context(), poll, result)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp")
+		return
+	}
+
+	submission := newChirp{
+		Body:       result.Text,
+		UserID:     userID,
+		InReplyTo:  nullUUID(params.InReplyTo),
+		QuoteOf:    nullUUID(params.QuoteOf),
+		MediaIDs:   mediaIDs,
+		Visibility: visibility,
+		Poll:       poll,
+	}
+
+	// A held chirp is published as soon as it is approved, even if it was
+	// scheduled for later.
+	switch result.Action {
+	case moderation.ActionReject:
+		respondWithError(w, http.StatusUnprocessableEntity, errChirpRejected.Error())
+		return
+	case moderation.ActionHold:
+		held, err := holdChirp(r.Context(), cfg.db, submission, uuid.NullUUID{}, result)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to hold chirp for review")
+			return
 		}
+		respondWithJSON(w, http.StatusAccepted, heldChirpFromDB(held))
+		return
+	}
 
-		respondWithJSON(w, 201, resp)
+	if params.PublishAt != nil {
+		cfg.scheduleChirp(w, r, submission, *params.PublishAt)
+		return
+	}
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := insertChirp(r.Context(), qtx, submission)
+	if err != nil {
+		respondWithInsertError(w, err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing chirp: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
 	}
 
+	respondWithJSON(w, 201, chirps[0])
 }
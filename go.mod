@@ -2,11 +2,12 @@ module github.com/Chase-Outman/GitLab
 
 go 1.23.3
 
-require golang.org/x/crypto v0.34.0
-
 require (
-	github.com/golang-jwt/jwt/v5 v5.2.1 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/google/uuid v1.6.0
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.34.0
 )
+
+require golang.org/x/sys v0.30.0 // indirect
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxBookmarkFolderName = 50
+
+// Bookmarks are private to the user who made them. Nothing here is exposed
+// through another user's requests, and chirps carry no bookmark count.
+
+type BookmarkFolder struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+}
+
+type bookmarkedChirp struct {
+	Chirp
+	FolderID     *uuid.UUID `json:"folder_id,omitempty"`
+	BookmarkedAt time.Time  `json:"bookmarked_at"`
+}
+
+type bookmarkPage struct {
+	Bookmarks []bookmarkedChirp `json:"bookmarks"`
+	Next      string            `json:"next,omitempty"`
+}
+
+func bookmarkFolderFromDB(folder database.BookmarkFolder) BookmarkFolder {
+	return BookmarkFolder{
+		ID:        folder.ID,
+		CreatedAt: folder.CreatedAt,
+		Name:      folder.Name,
+	}
+}
+
+func (cfg *apiConfig) handlerCreateBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxBookmarkFolderName {
+		respondWithError(w, http.StatusBadRequest, "name must be between 1 and 50 characters")
+		return
+	}
+
+	folder, err := cfg.db.CreateBookmarkFolder(r.Context(), database.CreateBookmarkFolderParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "you already have a folder with this name")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create bookmark folder")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, bookmarkFolderFromDB(folder))
+}
+
+func (cfg *apiConfig) handlerGetBookmarkFolders(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	rows, err := cfg.db.GetBookmarkFolders(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get bookmark folders")
+		return
+	}
+
+	folders := []BookmarkFolder{}
+	for _, row := range rows {
+		folders = append(folders, bookmarkFolderFromDB(row))
+	}
+
+	respondWithJSON(w, http.StatusOK, folders)
+}
+
+// handlerDeleteBookmarkFolder removes a folder. The bookmarks in it are kept
+// and become unfiled.
+func (cfg *apiConfig) handlerDeleteBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	folderID, err := uuid.Parse(r.PathValue("folderID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse folderID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	deleted, err := cfg.db.DeleteBookmarkFolder(r.Context(), database.DeleteBookmarkFolderParams{
+		ID:     folderID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to delete bookmark folder")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "failed to find bookmark folder with id")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// handlerBookmarkChirp saves a chirp, or moves an existing bookmark to
+// another folder. Leaving out folder_id files it nowhere.
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		FolderID *uuid.UUID `json:"folder_id"`
+	}
+
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+	viewer := uuid.NullUUID{UUID: userID, Valid: true}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpUuid)
+	if err != nil || chirpRemoved(chirp) {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+	visible, err := chirpVisibleTo(r.Context(), cfg.db, viewer, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp visibility")
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	if params.FolderID != nil {
+		_, err = cfg.db.GetBookmarkFolder(r.Context(), database.GetBookmarkFolderParams{
+			ID:     *params.FolderID,
+			UserID: userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "failed to find bookmark folder with id")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to get bookmark folder")
+			return
+		}
+	}
+
+	bookmark, err := cfg.db.UpsertBookmark(r.Context(), database.UpsertBookmarkParams{
+		UserID:   userID,
+		ChirpID:  chirp.ID,
+		FolderID: nullUUID(params.FolderID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to bookmark chirp")
+		return
+	}
+
+	batch, err := cfg.loadChirpBatch(r.Context(), viewer, []database.Chirp{chirp}, false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, bookmarkedChirpFromDB(batch.convert(chirp), bookmark.FolderID, bookmark.CreatedAt))
+}
+
+func (cfg *apiConfig) handlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	_, err = cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpUuid,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to remove bookmark")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerGetMyBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetBookmarkedChirpsParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if folderID := r.URL.Query().Get("folder_id"); folderID != "" {
+		id, err := uuid.Parse(folderID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "failed to parse folder_id to uuid")
+			return
+		}
+		params.FolderID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if page.cursor != nil {
+		params.CursorBookmarkedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorChirpID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetBookmarkedChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get bookmarks")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(row database.GetBookmarkedChirpsRow) (time.Time, uuid.UUID) {
+		return row.BookmarkedAt, row.Chirp.ID
+	})
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	chirps, err := cfg.listedChirpsForViewer(r, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	bookmarks := []bookmarkedChirp{}
+	for i, row := range rows {
+		bookmarks = append(bookmarks, bookmarkedChirpFromDB(chirps[i], row.FolderID, row.BookmarkedAt))
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, bookmarkPage{
+		Bookmarks: bookmarks,
+		Next:      next,
+	})
+}
+
+func bookmarkedChirpFromDB(chirp Chirp, folderID uuid.NullUUID, bookmarkedAt time.Time) bookmarkedChirp {
+	resp := bookmarkedChirp{
+		Chirp:        chirp,
+		BookmarkedAt: bookmarkedAt,
+	}
+	if folderID.Valid {
+		resp.FolderID = &folderID.UUID
+	}
+	return resp
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+type likedChirp struct {
+	Chirp
+	LikedAt time.Time `json:"liked_at"`
+}
+
+type likedChirpPage struct {
+	Likes []likedChirp `json:"likes"`
+	Next  string       `json:"next,omitempty"`
+}
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpUuid)
+	if err != nil || chirpRemoved(chirp) {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+	visible, err := chirpVisibleTo(r.Context(), qtx, uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp visibility")
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	liked, err := qtx.CreateChirpLike(r.Context(), database.CreateChirpLikeParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to like chirp")
+		return
+	}
+	if liked > 0 {
+		chirp, err = qtx.IncrementLikeCount(r.Context(), chirp.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to like chirp")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing chirp like: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpUuid)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	unliked, err := qtx.DeleteChirpLike(r.Context(), database.DeleteChirpLikeParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to unlike chirp")
+		return
+	}
+	if unliked > 0 {
+		_, err = qtx.DecrementLikeCount(r.Context(), chirp.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to unlike chirp")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing chirp unlike: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse user id to uuid")
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetLikedChirpsParams{
+		UserID:   userID,
+		ViewerID: cfg.viewerID(r),
+		Limit:    page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorLikedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorChirpID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetLikedChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get liked chirps")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(row database.GetLikedChirpsRow) (time.Time, uuid.UUID) {
+		return row.LikedAt, row.Chirp.ID
+	})
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	chirps, err := cfg.listedChirpsForViewer(r, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	likes := []likedChirp{}
+	for i, row := range rows {
+		likes = append(likes, likedChirp{
+			Chirp:   chirps[i],
+			LikedAt: row.LikedAt,
+		})
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, likedChirpPage{
+		Likes: likes,
+		Next:  next,
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+// How many chirps a user may keep pinned to their profile at once.
+const maxPins = 3
+const maxPinsChirpyRed = 10
+
+func pinLimit(user database.User) int64 {
+	if user.IsChirpyRed {
+		return maxPinsChirpyRed
+	}
+	return maxPins
+}
+
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpById(r.Context(), chirpUuid)
+	if err != nil || chirpRemoved(chirp) || chirp.UserID != userID {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	// Concurrent pins by the same user wait on the user row, so the count
+	// below always sees the pins the others made.
+	err = qtx.LockUserPins(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to pin chirp")
+		return
+	}
+	user, err := qtx.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to pin chirp")
+		return
+	}
+
+	pinned, err := qtx.CreatePin(r.Context(), database.CreatePinParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to pin chirp")
+		return
+	}
+	if pinned > 0 {
+		count, err := qtx.CountPins(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to pin chirp")
+			return
+		}
+		if count > pinLimit(user) {
+			respondWithError(w, http.StatusConflict, "you have already pinned as many chirps as you can")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing chirp pin: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+	chirps[0].Pinned = true
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	_, err = cfg.db.DeletePin(r.Context(), database.DeletePinParams{
+		UserID:  userID,
+		ChirpID: chirpUuid,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to unpin chirp")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// pinnedChirps returns the author's pinned chirps that the caller may see,
+// ready to go ahead of the rest of the listing.
+func (cfg *apiConfig) pinnedChirps(r *http.Request, authorID uuid.UUID) ([]Chirp, error) {
+	rows, err := cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+		UserID:   authorID,
+		ViewerID: cfg.viewerID(r),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chirps, err := cfg.listedChirpsForViewer(r, rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chirps {
+		chirps[i].Pinned = true
+	}
+	return chirps, nil
+}
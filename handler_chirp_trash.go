@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+const trashPurgeInterval = 10 * time.Minute
+
+// TrashedChirp is a deleted chirp as its owner sees it in the trash.
+type TrashedChirp struct {
+	Chirp
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type trashPage struct {
+	Chirps []TrashedChirp `json:"chirps"`
+	Next   string         `json:"next,omitempty"`
+}
+
+func (cfg *apiConfig) handlerGetTrash(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetTrashedChirpsParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorDeletedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetTrashedChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get trash")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(c database.Chirp) (time.Time, uuid.UUID) {
+		return c.DeletedAt.Time, c.ID
+	})
+
+	batch, err := cfg.loadChirpBatch(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, rows, true)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	// The owner still sees what they wrote, which batch.convert would hide.
+	chirps := []TrashedChirp{}
+	for _, row := range rows {
+		chirp := chirpFromDB(row)
+		batch.apply(&chirp)
+		chirps = append(chirps, TrashedChirp{
+			Chirp:     chirp,
+			DeletedAt: row.DeletedAt.Time,
+			PurgeAt:   row.DeletedAt.Time.Add(cfg.trashRetention),
+		})
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, trashPage{
+		Chirps: chirps,
+		Next:   next,
+	})
+}
+
+func (cfg *apiConfig) handlerRestoreChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.UserID != userID || !chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp in trash")
+		return
+	}
+	// The purge job may not have reached it yet.
+	if time.Now().UTC().After(chirp.DeletedAt.Time.Add(cfg.trashRetention)) {
+		respondWithError(w, http.StatusGone, "the restore window for this chirp has passed")
+		return
+	}
+
+	chirp, err = qtx.RestoreChirp(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to restore chirp")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing chirp restore: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+// runTrashPurger permanently deletes chirps whose restore window has passed
+// until ctx is done.
+func (cfg *apiConfig) runTrashPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			claimed, err := cfg.purgeNextTrashedChirp(ctx)
+			if err != nil {
+				log.Printf("Error purging trashed chirp: %s", err)
+			}
+			if !claimed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeNextTrashedChirp removes the oldest expired chirp in the trash, if
+// there is one, the same way an immediate delete would. A chirp that still
+// has replies or quotes is left behind as a tombstone. It reports whether it
+// claimed a chirp. A chirp that fails to purge is backed off before it is
+// tried again, so the purge can move on to the next one.
+func (cfg *apiConfig) purgeNextTrashedChirp(ctx context.Context) (bool, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	expiredBefore := time.Now().UTC().Add(-cfg.trashRetention)
+	chirp, err := qtx.ClaimExpiredTrashedChirp(ctx, sql.NullTime{Time: expiredBefore, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = removeChirp(ctx, qtx, chirp)
+	if err == nil {
+		err = qtx.ClearTrashPurgeFailure(ctx, chirp.ID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		tx.Rollback()
+		markErr := cfg.db.RecordTrashPurgeFailure(ctx, database.RecordTrashPurgeFailureParams{
+			ChirpID: chirp.ID,
+			Failure: err.Error(),
+		})
+		if markErr != nil {
+			return false, markErr
+		}
+		return true, fmt.Errorf("chirp %s: %w", chirp.ID, err)
+	}
+	return true, nil
+}
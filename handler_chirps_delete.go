@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/Chase-Outman/GitLab/internal/auth"
+	"github.com/Chase-Outman/GitLab/internal/database"
 	"github.com/google/uuid"
 )
 
@@ -26,14 +31,22 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.secret)
+	userID, err := auth.ValidateJWT(token, cfg.jwtKeys)
 	if err != nil {
 		respondWithError(w, http.StatusForbidden, "failed to validate JWT")
 		return
 	}
 
-	chirp, err := cfg.db.GetChirpById(r.Context(), chirpUuid)
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
 	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpUuid)
+	if err != nil || chirpRemoved(chirp) {
 		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
 		return
 	}
@@ -43,7 +56,27 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = cfg.db.DeleteChirp(r.Context(), chirp.ID)
+	// A rechirp has nothing worth restoring, so it is removed straight away.
+	// Anything else goes to the trash until it is restored or purged.
+	if chirp.RechirpOf.Valid {
+		err = removeChirp(r.Context(), qtx, chirp)
+	} else {
+		err = qtx.TrashChirp(r.Context(), database.TrashChirpParams{
+			ID:        chirp.ID,
+			DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
+		// A trashed chirp gives up its pin, so it no longer counts against
+		// the owner's limit while it waits to be restored or purged.
+		if err == nil {
+			err = qtx.DeleteChirpPins(r.Context(), chirp.ID)
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to delete chirp from database")
+		return
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "failed to delete chirp from database")
 		return
@@ -52,3 +85,90 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 	respondWithJSON(w, http.StatusNoContent, nil)
 
 }
+
+// removeChirp permanently deletes a chirp without breaking the conversations it belongs
+// to. A chirp that still has replies or quotes is replaced by a tombstone so
+// they keep pointing at something; plain rechirps of it are removed either
+// way. Otherwise the row is deleted outright.
+func removeChirp(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if chirp.ReplyCount > 0 || chirp.QuoteCount > 0 {
+		err := qtx.DeleteChirpRevisions(ctx, chirp.ID)
+		if err != nil {
+			return err
+		}
+		err = qtx.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		err = qtx.DeleteChirpHashtags(ctx, chirp.ID)
+		if err != nil {
+			return err
+		}
+		err = qtx.DeleteChirpMentions(ctx, chirp.ID)
+		if err != nil {
+			return err
+		}
+		err = qtx.DetachChirpMedia(ctx, chirp.ID)
+		if err != nil {
+			return err
+		}
+		err = qtx.DeletePoll(ctx, chirp.ID)
+		if err != nil {
+			return err
+		}
+		return qtx.TombstoneChirp(ctx, chirp.ID)
+	}
+
+	err := qtx.DeleteChirp(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+	return releaseReferences(ctx, qtx, chirp)
+}
+
+// releaseReferences updates the counters on the chirps a deleted chirp was
+// replying to, quoting or rechirping, and deletes any tombstone that no
+// longer has anything pointing at it.
+func releaseReferences(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if chirp.RechirpOf.Valid {
+		err := qtx.DecrementRechirpCount(ctx, chirp.RechirpOf.UUID)
+		if err != nil {
+			return err
+		}
+	}
+
+	parents := []database.Chirp{}
+	if chirp.InReplyTo.Valid {
+		parent, err := qtx.DecrementReplyCount(ctx, chirp.InReplyTo.UUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			parents = append(parents, parent)
+		}
+	}
+	if chirp.QuoteOf.Valid {
+		parent, err := qtx.DecrementQuoteCount(ctx, chirp.QuoteOf.UUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			parents = append(parents, parent)
+		}
+	}
+
+	for _, parent := range parents {
+		if !parent.TombstonedAt.Valid || parent.ReplyCount > 0 || parent.QuoteCount > 0 {
+			continue
+		}
+		err := qtx.DeleteChirp(ctx, parent.ID)
+		if err != nil {
+			return err
+		}
+		err = releaseReferences(ctx, qtx, parent)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
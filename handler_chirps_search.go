@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/search"
+	"github.com/google/uuid"
+)
+
+type searchCursor struct {
+	Rank      float32   `json:"r"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// chirpSearchResult carries a snippet of the body around the matches. The
+// snippet is HTML: the body is escaped and each match wrapped in <mark>.
+type chirpSearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type chirpSearchPage struct {
+	Results []chirpSearchResult `json:"results"`
+	Next    string              `json:"next,omitempty"`
+}
+
+// parseSearchTime accepts either a full RFC 3339 timestamp or a plain date.
+func parseSearchTime(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			return sql.NullTime{}, errors.New("dates must be RFC 3339 timestamps or YYYY-MM-DD")
+		}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	tsQuery, err := search.ToTSQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parsePageLimit(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.SearchChirpsParams{
+		Query:    tsQuery,
+		ViewerID: cfg.viewerID(r),
+		Limit:    int32(limit + 1),
+	}
+
+	if authorID := query.Get("author_id"); authorID != "" {
+		userID, err := uuid.Parse(authorID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "failed to parse provided author id to UUID")
+			return
+		}
+		params.AuthorID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	params.Since, err = parseSearchTime(query.Get("since"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params.Until, err = parseSearchTime(query.Get("until"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		c := searchCursor{}
+		err := decodeCursor(cursor, &c)
+		if err != nil || c.ID == uuid.Nil {
+			respondWithError(w, http.StatusBadRequest, "malformed cursor")
+			return
+		}
+		params.CursorRank = sql.NullFloat64{Float64: float64(c.Rank), Valid: true}
+		params.CursorCreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.SearchChirps(r.Context(), params)
+	if err != nil {
+		log.Printf("Error searching chirps in database: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		next = encodeCursor(searchCursor{Rank: last.Rank, CreatedAt: last.Chirp.CreatedAt, ID: last.Chirp.ID})
+	}
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	chirps, err := cfg.listedChirpsForViewer(r, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	results := []chirpSearchResult{}
+	for i, row := range rows {
+		results = append(results, chirpSearchResult{
+			Chirp:   chirps[i],
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, chirpSearchPage{
+		Results: results,
+		Next:    next,
+	})
+}
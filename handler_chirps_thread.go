@@ -0,0 +1,156 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+const defaultThreadDepth = 3
+const maxThreadDepth = 10
+const maxThreadDescendants = 500
+
+type threadReply struct {
+	Chirp
+	Replies []threadReply `json:"replies"`
+}
+
+type chirpThread struct {
+	Ancestors []Chirp       `json:"ancestors"`
+	Chirp     Chirp         `json:"chirp"`
+	Replies   []threadReply `json:"replies"`
+	Next      string        `json:"next,omitempty"`
+}
+
+// buildReplyTree nests the replies to parentID, stopping once depth levels
+// have been filled in. Deeper replies are still counted in reply_count.
+func buildReplyTree(children map[uuid.UUID][]database.Chirp, parentID uuid.UUID, depth int, batch chirpBatch) []threadReply {
+	replies := []threadReply{}
+	if depth <= 0 {
+		return replies
+	}
+	for _, child := range children[parentID] {
+		replies = append(replies, threadReply{
+			Chirp:   batch.convert(child),
+			Replies: buildReplyTree(children, child.ID, depth-1, batch),
+		})
+	}
+	return replies
+}
+
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	depth := defaultThreadDepth
+	if d := r.URL.Query().Get("depth"); d != "" {
+		depth, err = strconv.Atoi(d)
+		if err != nil || depth < 1 {
+			respondWithError(w, http.StatusBadRequest, "depth must be a positive integer")
+			return
+		}
+		depth = min(depth, maxThreadDepth)
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpUuid)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	// Chirps the viewer cannot read are left out of the thread, along with
+	// any replies beneath them.
+	viewer := cfg.viewerID(r)
+	visible, err := chirpVisibleTo(r.Context(), cfg.db, viewer, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp visibility")
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	dbAncestors, err := cfg.db.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ID:       chirp.ID,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get chirp ancestors")
+		return
+	}
+
+	replyParams := database.GetChirpRepliesParams{
+		ParentID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		ViewerID: viewer,
+		Limit:    page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		replyParams.CursorCreatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		replyParams.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+	dbReplies, err := cfg.db.GetChirpReplies(r.Context(), replyParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get chirp replies")
+		return
+	}
+	dbReplies, next, _ := buildPage(dbReplies, page, func(c database.Chirp) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+
+	children := map[uuid.UUID][]database.Chirp{chirp.ID: dbReplies}
+	threadChirps := slices.Concat([]database.Chirp{chirp}, dbAncestors, dbReplies)
+	if depth > 1 && len(dbReplies) > 0 {
+		replyIDs := make([]uuid.UUID, len(dbReplies))
+		for i, reply := range dbReplies {
+			replyIDs[i] = reply.ID
+		}
+		descendants, err := cfg.db.GetReplyDescendants(r.Context(), database.GetReplyDescendantsParams{
+			ParentIds: replyIDs,
+			ViewerID:  viewer,
+			MaxDepth:  int32(depth - 1),
+			Limit:     maxThreadDescendants,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to get chirp replies")
+			return
+		}
+		for _, d := range descendants {
+			children[d.InReplyTo.UUID] = append(children[d.InReplyTo.UUID], d)
+			threadChirps = append(threadChirps, d)
+		}
+	}
+
+	batch, err := cfg.loadChirpBatch(r.Context(), viewer, threadChirps, false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	ancestors := []Chirp{}
+	for _, dbAncestor := range dbAncestors {
+		ancestors = append(ancestors, batch.convert(dbAncestor))
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, chirpThread{
+		Ancestors: ancestors,
+		Chirp:     batch.convert(chirp),
+		Replies:   buildReplyTree(children, chirp.ID, depth, batch),
+		Next:      next,
+	})
+}
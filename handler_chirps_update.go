@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/moderation"
+	"github.com/google/uuid"
+)
+
+type ChirpRevision struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	Body      string    `json:"body"`
+}
+
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+
+	result, err := cfg.checkChirpBody(r.Context(), params.Body)
+	if errors.Is(err, errChirpTooLong) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp")
+		return
+	}
+	if result.Action == moderation.ActionReject {
+		respondWithError(w, http.StatusUnprocessableEntity, errChirpRejected.Error())
+		return
+	}
+	body := result.Text
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpUuid)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && chirpRemoved(chirp)) {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get chirp from database")
+		return
+	}
+
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "user not allowed to edit chirp")
+		return
+	}
+
+	if chirp.RechirpOf.Valid {
+		respondWithError(w, http.StatusBadRequest, "rechirps cannot be edited")
+		return
+	}
+
+	if chirp.QuoteOf.Valid && body == "" {
+		respondWithError(w, http.StatusBadRequest, errQuoteNeedsBody.Error())
+		return
+	}
+
+	// An unchanged body is not an edit, but the response is the same chirp
+	// the edit would have returned.
+	if chirp.Body == body {
+		tx.Rollback()
+		chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, chirps[0])
+		return
+	}
+
+	if result.Action == moderation.ActionHold {
+		held, err := holdChirp(r.Context(), qtx, newChirp{Body: body, UserID: userID, Visibility: chirp.Visibility}, uuid.NullUUID{UUID: chirp.ID, Valid: true}, result)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to hold edit for review")
+			return
+		}
+		err = tx.Commit()
+		if err != nil {
+			log.Printf("Error committing held edit: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		respondWithJSON(w, http.StatusAccepted, heldChirpFromDB(held))
+		return
+	}
+
+	chirp, err = editChirp(r.Context(), qtx, chirp, body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing chirp update: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+// editChirp replaces the body of a locked chirp, keeping the old body as a
+// revision and refreshing its hashtags and mentions.
+func editChirp(ctx context.Context, qtx *database.Queries, chirp database.Chirp, body string) (database.Chirp, error) {
+	_, err := qtx.CreateChirpRevision(ctx, database.CreateChirpRevisionParams{
+		ChirpID: chirp.ID,
+		Body:    chirp.Body,
+	})
+	if err != nil {
+		return chirp, err
+	}
+
+	chirp, err = qtx.UpdateChirpBody(ctx, database.UpdateChirpBodyParams{
+		Body: body,
+		ID:   chirp.ID,
+	})
+	if err != nil {
+		return chirp, err
+	}
+
+	err = tagChirp(ctx, qtx, chirp)
+	if err != nil {
+		return chirp, err
+	}
+	err = mentionUsers(ctx, qtx, chirp)
+	return chirp, err
+}
+
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpUuid)
+	if err != nil || chirpRemoved(chirp) {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+	visible, err := chirpVisibleTo(r.Context(), cfg.db, cfg.viewerID(r), chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp visibility")
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	dbRevisions, err := cfg.db.GetChirpRevisions(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get chirp revisions")
+		return
+	}
+
+	revisions := []ChirpRevision{}
+	for _, revision := range dbRevisions {
+		revisions = append(revisions, ChirpRevision{
+			ID:        revision.ID,
+			CreatedAt: revision.CreatedAt,
+			ChirpID:   revision.ChirpID,
+			Body:      revision.Body,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, revisions)
+}
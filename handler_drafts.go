@@ -0,0 +1,350 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/moderation"
+	"github.com/google/uuid"
+)
+
+// maxDraftLength keeps drafts to a sane size. The chirp length limit only
+// applies when a draft is published.
+const maxDraftLength = 10000
+
+var errDraftTooLong = errors.New("Draft is too long")
+
+type Draft struct {
+	ID         uuid.UUID   `json:"id"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	Body       string      `json:"body"`
+	InReplyTo  *uuid.UUID  `json:"in_reply_to,omitempty"`
+	QuoteOf    *uuid.UUID  `json:"quote_of,omitempty"`
+	MediaIDs   []uuid.UUID `json:"media_ids"`
+	Visibility string      `json:"visibility"`
+}
+
+type draftPage struct {
+	Drafts []Draft `json:"drafts"`
+	Next   string  `json:"next,omitempty"`
+}
+
+func draftFromDB(draft database.Draft) Draft {
+	resp := Draft{
+		ID:         draft.ID,
+		CreatedAt:  draft.CreatedAt,
+		UpdatedAt:  draft.UpdatedAt,
+		Body:       draft.Body,
+		MediaIDs:   draft.MediaIds,
+		Visibility: draft.Visibility,
+	}
+	if draft.InReplyTo.Valid {
+		resp.InReplyTo = &draft.InReplyTo.UUID
+	}
+	if draft.QuoteOf.Valid {
+		resp.QuoteOf = &draft.QuoteOf.UUID
+	}
+	return resp
+}
+
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := cfg.readDraft(w, r)
+	if !ok {
+		return
+	}
+
+	row, err := cfg.db.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:     draft.UserID,
+		Body:       draft.Body,
+		InReplyTo:  draft.InReplyTo,
+		QuoteOf:    draft.QuoteOf,
+		MediaIds:   draft.MediaIDs,
+		Visibility: draft.Visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to save draft")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, draftFromDB(row))
+}
+
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetDraftsParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorUpdatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetDrafts(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get drafts")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(draft database.Draft) (time.Time, uuid.UUID) {
+		return draft.UpdatedAt, draft.ID
+	})
+
+	drafts := []Draft{}
+	for _, row := range rows {
+		drafts = append(drafts, draftFromDB(row))
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, draftPage{
+		Drafts: drafts,
+		Next:   next,
+	})
+}
+
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse draftID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	draft, err := cfg.db.GetDraft(r.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find draft with id")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftFromDB(draft))
+}
+
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse draftID to uuid")
+		return
+	}
+
+	draft, ok := cfg.readDraft(w, r)
+	if !ok {
+		return
+	}
+
+	row, err := cfg.db.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body:       draft.Body,
+		InReplyTo:  draft.InReplyTo,
+		QuoteOf:    draft.QuoteOf,
+		MediaIds:   draft.MediaIDs,
+		Visibility: draft.Visibility,
+		ID:         draftID,
+		UserID:     draft.UserID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "failed to find draft with id")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to save draft")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftFromDB(row))
+}
+
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse draftID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	deleted, err := cfg.db.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to delete draft")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "failed to find draft with id")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// handlerPublishDraft turns a draft into a chirp. The draft is locked and
+// deleted in the transaction that creates the chirp, so publishing twice
+// can never make two chirps.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse draftID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	draft, err := qtx.GetDraftForUpdate(r.Context(), database.GetDraftForUpdateParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find draft with id")
+		return
+	}
+
+	result, err := cfg.checkChirpBody(r.Context(), draft.Body)
+	if errors.Is(err, errChirpTooLong) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp")
+		return
+	}
+
+	submission := newChirp{
+		Body:       result.Text,
+		UserID:     userID,
+		InReplyTo:  draft.InReplyTo,
+		QuoteOf:    draft.QuoteOf,
+		MediaIDs:   draft.MediaIds,
+		Visibility: draft.Visibility,
+	}
+
+	var chirp database.Chirp
+	var held database.HeldChirp
+	switch result.Action {
+	case moderation.ActionReject:
+		respondWithError(w, http.StatusUnprocessableEntity, errChirpRejected.Error())
+		return
+	case moderation.ActionHold:
+		held, err = holdChirp(r.Context(), qtx, submission, uuid.NullUUID{}, result)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to hold chirp for review")
+			return
+		}
+	default:
+		chirp, err = insertChirp(r.Context(), qtx, submission)
+		if err != nil {
+			respondWithInsertError(w, err)
+			return
+		}
+	}
+
+	_, err = qtx.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draft.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to remove draft")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing draft publish: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if result.Action == moderation.ActionHold {
+		respondWithJSON(w, http.StatusAccepted, heldChirpFromDB(held))
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirps[0])
+}
+
+// readDraft reads a draft from the request body and checks it the way
+// handlerChirps checks a chirp, leaving out the length limit. Bodies that
+// would be rejected outright are refused; censoring and holding happen when
+// the draft is published, so the owner gets back what they wrote. It writes
+// the error response when the draft is not acceptable.
+func (cfg *apiConfig) readDraft(w http.ResponseWriter, r *http.Request) (newChirp, bool) {
+	type parameters struct {
+		Body       string      `json:"body"`
+		InReplyTo  *uuid.UUID  `json:"in_reply_to"`
+		QuoteOf    *uuid.UUID  `json:"quote_of"`
+		MediaIDs   []uuid.UUID `json:"media_ids"`
+		Visibility string      `json:"visibility"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return newChirp{}, false
+	}
+	if len(params.Body) > maxDraftLength {
+		respondWithError(w, http.StatusBadRequest, errDraftTooLong.Error())
+		return newChirp{}, false
+	}
+
+	mediaIDs, err := parseMediaIDs(params.MediaIDs)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return newChirp{}, false
+	}
+
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return newChirp{}, false
+	}
+
+	result, err := cfg.moderateBody(r.Context(), params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check draft")
+		return newChirp{}, false
+	}
+	if result.Action == moderation.ActionReject {
+		respondWithError(w, http.StatusUnprocessableEntity, errChirpRejected.Error())
+		return newChirp{}, false
+	}
+
+	return newChirp{
+		Body:       params.Body,
+		UserID:     r.Context().Value("userID").(uuid.UUID),
+		InReplyTo:  nullUUID(params.InReplyTo),
+		QuoteOf:    nullUUID(params.QuoteOf),
+		MediaIDs:   mediaIDs,
+		Visibility: visibility,
+	}, true
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+type followUser struct {
+	ID         uuid.UUID `json:"id"`
+	Username   string    `json:"username,omitempty"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type followPage struct {
+	Users []followUser `json:"users"`
+	Count int32        `json:"count"`
+	Next  string       `json:"next,omitempty"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	cfg.updateFollow(w, r, true)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	cfg.updateFollow(w, r, false)
+}
+
+// updateFollow creates or removes a follow edge and keeps both users'
+// counters in step with it. Repeating either call is a no-op.
+func (cfg *apiConfig) updateFollow(w http.ResponseWriter, r *http.Request, follow bool) {
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse user id to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "you cannot follow yourself")
+		return
+	}
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find user with id")
+		return
+	}
+
+	edge := database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	}
+	var changed int64
+	var delta int32
+	if follow {
+		changed, err = qtx.CreateFollow(r.Context(), edge)
+		delta = 1
+	} else {
+		changed, err = qtx.DeleteFollow(r.Context(), database.DeleteFollowParams(edge))
+		delta = -1
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update follow")
+		return
+	}
+	if changed > 0 {
+		err = qtx.UpdateFollowCounts(r.Context(), database.UpdateFollowCountsParams{
+			FollowerID: userID,
+			Delta:      delta,
+			FolloweeID: followeeID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to update follow")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing follow: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, true)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, false)
+}
+
+// listFollows pages through the accounts following a user, or the accounts
+// the user follows, most recent first.
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, followers bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse user id to uuid")
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find user with id")
+		return
+	}
+
+	params := database.GetFollowersParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorFollowedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorUserID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	var rows []database.GetFollowersRow
+	count := user.FollowerCount
+	if followers {
+		rows, err = cfg.db.GetFollowers(r.Context(), params)
+	} else {
+		var following []database.GetFollowingRow
+		following, err = cfg.db.GetFollowing(r.Context(), database.GetFollowingParams(params))
+		for _, row := range following {
+			rows = append(rows, database.GetFollowersRow(row))
+		}
+		count = user.FollowingCount
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get follows")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(row database.GetFollowersRow) (time.Time, uuid.UUID) {
+		return row.FollowedAt, row.ID
+	})
+
+	users := []followUser{}
+	for _, row := range rows {
+		users = append(users, followUser{
+			ID:         row.ID,
+			Username:   row.Username.String,
+			FollowedAt: row.FollowedAt,
+		})
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, followPage{
+		Users: users,
+		Count: count,
+		Next:  next,
+	})
+}
+
+// handlerGetTimeline serves the chirps of everyone the caller follows, plus
+// their own, newest first. GetTimeline reads at most one page of rows per
+// followed account through the (user_id, created_at, id) index, so the cost
+// follows the size of the follow list rather than the chirps table.
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetTimelineParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	dbChirps, err := cfg.db.GetTimeline(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get timeline")
+		return
+	}
+	dbChirps, next, _ := buildPage(dbChirps, page, func(c database.Chirp) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+
+	chirps, err := cfg.listedChirpsForViewer(r, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps: chirps,
+		Next:   next,
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/entities"
+	"github.com/Chase-Outman/GitLab/internal/trending"
+	"github.com/google/uuid"
+)
+
+const defaultTrendingLimit = 10
+const maxTrendingLimit = 50
+const minTrendingCount = 2
+
+var trendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+type TrendingTag struct {
+	Tag           string  `json:"tag"`
+	RecentCount   int64   `json:"recent_count"`
+	BaselineCount int64   `json:"baseline_count"`
+	Score         float64 `json:"score"`
+}
+
+// tagChirp replaces the hashtags recorded for a chirp with the ones in its
+// current body.
+func tagChirp(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	err := qtx.DeleteChirpHashtags(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+
+	tags := entities.UniqueTags(entities.Hashtags(chirp.Body))
+	if len(tags) == 0 {
+		return nil
+	}
+
+	err = qtx.UpsertHashtags(ctx, tags)
+	if err != nil {
+		return err
+	}
+	return qtx.TagChirp(ctx, database.TagChirpParams{
+		ChirpID:   chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		Tags:      tags,
+	})
+}
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetHashtagChirpsParams{
+		Tag:      tag,
+		ViewerID: cfg.viewerID(r),
+		Limit:    page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetHashtagChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get hashtag chirps")
+		return
+	}
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	dbChirps, next, _ := buildPage(dbChirps, page, func(c database.Chirp) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+
+	chirps, err := cfg.listedChirpsForViewer(r, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps: chirps,
+		Next:   next,
+	})
+}
+
+func (cfg *apiConfig) handlerGetTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "24h"
+	}
+	windowLength, ok := trendingWindows[window]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "window must be one of 1h, 24h or 7d")
+		return
+	}
+
+	limit := defaultTrendingLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxTrendingLimit)
+	}
+
+	now := time.Now().UTC()
+	windowStart := now.Add(-windowLength)
+	rows, err := cfg.db.GetHashtagCounts(r.Context(), database.GetHashtagCountsParams{
+		WindowStart:   windowStart,
+		BaselineStart: windowStart.Add(-trending.BaselineWindows * windowLength),
+		MinCount:      minTrendingCount,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get hashtag counts")
+		return
+	}
+
+	tags := make([]trending.Tag, len(rows))
+	for i, row := range rows {
+		tags[i] = trending.Tag{
+			Tag:           row.Tag,
+			RecentCount:   row.RecentCount,
+			BaselineCount: row.BaselineCount,
+		}
+	}
+
+	resp := []TrendingTag{}
+	for _, tag := range trending.Rank(tags, limit) {
+		resp = append(resp, TrendingTag{
+			Tag:           tag.Tag,
+			RecentCount:   tag.RecentCount,
+			BaselineCount: tag.BaselineCount,
+			Score:         tag.Score,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
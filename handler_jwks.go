@@ -0,0 +1,12 @@
+package main
+
+import (
+	"net/http"
+)
+
+// handlerJWKS publishes the public keys access tokens can be verified with,
+// so other services never need our private key.
+func (cfg *apiConfig) handlerJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, cfg.jwtKeys.JWKS())
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/media"
+	"github.com/google/uuid"
+)
+
+const maxUploadSize = 10 << 20
+const maxAltTextLength = 1000
+const maxChirpMedia = 4
+
+var errInvalidMedia = errors.New("media must be your own uploads that are not attached to another chirp")
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	AltText      string    `json:"alt_text"`
+}
+
+func mediaFromDB(file database.MediaFile) Media {
+	url := "/api/media/" + file.ID.String()
+	return Media{
+		ID:           file.ID,
+		URL:          url,
+		ThumbnailURL: url + "/thumbnail",
+		ContentType:  file.ContentType,
+		SizeBytes:    file.SizeBytes,
+		Width:        file.Width,
+		Height:       file.Height,
+		AltText:      file.AltText,
+	}
+}
+
+// parseMediaIDs checks the media a chirp asks to attach. Ownership is
+// checked when they are attached.
+func parseMediaIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) > maxChirpMedia {
+		return nil, errors.New("a chirp can have at most 4 media attachments")
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return nil, errors.New("media can only be attached once")
+		}
+		seen[id] = true
+	}
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	return ids, nil
+}
+
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	// Leave room for the multipart framing and the alt text.
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize+64<<10)
+	err := r.ParseMultipartForm(maxUploadSize)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "media must be at most 10MB")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse multipart form")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "missing file")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxUploadSize+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to read file")
+		return
+	}
+	if len(data) > maxUploadSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "media must be at most 10MB")
+		return
+	}
+
+	altText := r.FormValue("alt_text")
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		respondWithError(w, http.StatusBadRequest, "alt text is too long")
+		return
+	}
+
+	info, err := media.Inspect(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "media must be a JPEG, PNG or GIF image")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.storage.Put(r.Context(), info.Hash, bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Error storing media: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to store media")
+		return
+	}
+
+	// Identical content has already been thumbnailed; reuse that rather
+	// than decoding the image again.
+	thumbnailHash := sql.NullString{}
+	existing, err := cfg.db.GetMediaFileByHash(r.Context(), info.Hash)
+	if err == nil {
+		thumbnailHash = existing.ThumbnailHash
+	} else if errors.Is(err, sql.ErrNoRows) {
+		thumb, err := media.Thumbnail(data, info)
+		if err != nil {
+			log.Printf("Error making thumbnail: %s", err)
+			respondWithError(w, http.StatusBadRequest, "failed to decode image")
+			return
+		}
+		if thumb != nil {
+			thumbnailHash = sql.NullString{String: media.Hash(thumb), Valid: true}
+			err = cfg.storage.Put(r.Context(), thumbnailHash.String, bytes.NewReader(thumb))
+			if err != nil {
+				log.Printf("Error storing thumbnail: %s", err)
+				respondWithError(w, http.StatusInternalServerError, "failed to store media")
+				return
+			}
+		}
+	} else {
+		respondWithError(w, http.StatusInternalServerError, "failed to look up media")
+		return
+	}
+
+	row, err := cfg.db.CreateMediaFile(r.Context(), database.CreateMediaFileParams{
+		UserID:        userID,
+		ContentHash:   info.Hash,
+		ContentType:   info.ContentType,
+		SizeBytes:     int64(len(data)),
+		Width:         int32(info.Width),
+		Height:        int32(info.Height),
+		ThumbnailHash: thumbnailHash,
+		AltText:       altText,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to save media")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, mediaFromDB(row))
+}
+
+func (cfg *apiConfig) handlerUpdateMedia(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		AltText string `json:"alt_text"`
+	}
+
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse mediaID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+	if utf8.RuneCountInString(params.AltText) > maxAltTextLength {
+		respondWithError(w, http.StatusBadRequest, "alt text is too long")
+		return
+	}
+
+	row, err := cfg.db.UpdateMediaAltText(r.Context(), database.UpdateMediaAltTextParams{
+		AltText: params.AltText,
+		ID:      mediaID,
+		UserID:  userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "failed to find media with id")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update media")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mediaFromDB(row))
+}
+
+func (cfg *apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) handlerGetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+var errMediaHidden = errors.New("media is not visible to viewer")
+
+// mediaVisibleTo checks whether the caller may fetch a media file, which
+// follows the chirp it is attached to. Media not attached to a live chirp,
+// whether just uploaded, in a draft or held for review, is only served to
+// its uploader and to admins. It reports whether the media is public.
+func (cfg *apiConfig) mediaVisibleTo(r *http.Request, file database.MediaFile) (bool, error) {
+	viewer := cfg.viewerID(r)
+
+	chirp, err := cfg.db.GetMediaChirp(r.Context(), file.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	if err == nil && !chirpRemoved(chirp) {
+		visible, err := chirpVisibleTo(r.Context(), cfg.db, viewer, chirp)
+		if err != nil {
+			return false, err
+		}
+		if !visible {
+			return false, errMediaHidden
+		}
+		return chirp.Visibility == visibilityPublic, nil
+	}
+
+	if !viewer.Valid {
+		return false, errMediaHidden
+	}
+	if viewer.UUID == file.UserID {
+		return false, nil
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), viewer.UUID)
+	if err != nil || !user.IsAdmin {
+		return false, errMediaHidden
+	}
+	return false, nil
+}
+
+// serveMedia streams a stored blob. Images small enough to need no
+// thumbnail serve the original in its place.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse mediaID to uuid")
+		return
+	}
+
+	file, err := cfg.db.GetMediaFileByID(r.Context(), mediaID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find media with id")
+		return
+	}
+
+	public, err := cfg.mediaVisibleTo(r, file)
+	if errors.Is(err, errMediaHidden) {
+		respondWithError(w, http.StatusNotFound, "failed to find media with id")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check media visibility")
+		return
+	}
+
+	key, contentType := file.ContentHash, file.ContentType
+	if thumbnail && file.ThumbnailHash.Valid {
+		key, contentType = file.ThumbnailHash.String, media.ThumbnailType(file.ContentType)
+	}
+
+	blob, err := cfg.storage.Open(r.Context(), key)
+	if errors.Is(err, media.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "failed to find media with id")
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening media: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to open media")
+		return
+	}
+	defer blob.Close()
+
+	// Blobs are addressed by their content hash, so a response never goes
+	// stale. Only media on public chirps may sit in shared caches.
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if public {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+		w.Header().Add("Vary", "Authorization")
+	}
+	w.Header().Set("ETag", `"`+key+`"`)
+	http.ServeContent(w, r, "", file.CreatedAt, blob)
+}
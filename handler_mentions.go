@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/entities"
+	"github.com/google/uuid"
+)
+
+type ChirpMention struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Start    int32     `json:"start"`
+	End      int32     `json:"end"`
+}
+
+// mentionUsers replaces the mentions recorded for a chirp with the ones in
+// its current body. Mentions of usernames nobody holds are left as text.
+func mentionUsers(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	err := qtx.DeleteChirpMentions(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+
+	mentions := entities.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	usernames := []string{}
+	for _, m := range mentions {
+		usernames = append(usernames, strings.ToLower(m.Username))
+	}
+	users, err := qtx.GetUsersByUsernames(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	userIDs := map[string]uuid.UUID{}
+	for _, u := range users {
+		userIDs[strings.ToLower(u.Username.String)] = u.ID
+	}
+
+	params := database.CreateChirpMentionsParams{ChirpID: chirp.ID}
+	for _, m := range mentions {
+		userID, ok := userIDs[strings.ToLower(m.Username)]
+		if !ok {
+			continue
+		}
+		params.UserIds = append(params.UserIds, userID)
+		params.StartOffsets = append(params.StartOffsets, int32(m.Start))
+		params.EndOffsets = append(params.EndOffsets, int32(m.End))
+	}
+	if len(params.UserIds) == 0 {
+		return nil
+	}
+	return qtx.CreateChirpMentions(ctx, params)
+}
+
+func (cfg *apiConfig) handlerGetMyMentions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetMentioningChirpsParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetMentioningChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get mentions")
+		return
+	}
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	dbChirps, next, _ := buildPage(dbChirps, page, func(c database.Chirp) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+
+	chirps, err := cfg.listedChirpsForViewer(r, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps: chirps,
+		Next:   next,
+	})
+}
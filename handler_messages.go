@@ -0,0 +1,541 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const maxMessageLength = 1000
+const maxConversationParticipants = 20
+
+var errMessageTooLong = errors.New("Message is too long")
+var errMessageRejected = errors.New("message breaks the content rules")
+
+// Conversation is a private thread between two or more users, as one of
+// them sees it. Only participants can read or post to it.
+type Conversation struct {
+	ID           uuid.UUID                 `json:"id"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	Participants []ConversationParticipant `json:"participants"`
+	UnreadCount  int64                     `json:"unread_count"`
+}
+
+// ConversationParticipant carries each participant's read marker: the last
+// message they have read, if any.
+type ConversationParticipant struct {
+	UserID            uuid.UUID  `json:"user_id"`
+	Username          string     `json:"username,omitempty"`
+	LastReadMessageID *uuid.UUID `json:"last_read_message_id,omitempty"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+type conversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	Next          string         `json:"next,omitempty"`
+}
+
+type messagePage struct {
+	Messages []Message `json:"messages"`
+	Next     string    `json:"next,omitempty"`
+}
+
+func messageFromDB(message database.Message) Message {
+	return Message{
+		ID:             message.ID,
+		CreatedAt:      message.CreatedAt,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+	}
+}
+
+// conversationsFromDB converts conversations and fills in their
+// participants with one query.
+func conversationsFromDB(ctx context.Context, q *database.Queries, rows []database.GetConversationsRow) ([]Conversation, error) {
+	ids := []uuid.UUID{}
+	for _, row := range rows {
+		ids = append(ids, row.Conversation.ID)
+	}
+
+	participants := map[uuid.UUID][]ConversationParticipant{}
+	if len(ids) > 0 {
+		prows, err := q.GetConversationParticipants(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range prows {
+			participant := ConversationParticipant{
+				UserID:   p.UserID,
+				Username: p.Username.String,
+			}
+			if p.LastReadMessageID.Valid {
+				participant.LastReadMessageID = &p.LastReadMessageID.UUID
+			}
+			participants[p.ConversationID] = append(participants[p.ConversationID], participant)
+		}
+	}
+
+	conversations := []Conversation{}
+	for _, row := range rows {
+		conversations = append(conversations, Conversation{
+			ID:           row.Conversation.ID,
+			CreatedAt:    row.Conversation.CreatedAt,
+			UpdatedAt:    row.Conversation.UpdatedAt,
+			Participants: participants[row.Conversation.ID],
+			UnreadCount:  row.UnreadCount,
+		})
+	}
+	return conversations, nil
+}
+
+// conversationForUser loads one conversation as userID sees it. It returns
+// sql.ErrNoRows when userID is not a participant.
+func conversationForUser(ctx context.Context, q *database.Queries, conversationID, userID uuid.UUID) (Conversation, error) {
+	row, err := q.GetConversation(ctx, database.GetConversationParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		return Conversation{}, err
+	}
+	conversations, err := conversationsFromDB(ctx, q, []database.GetConversationsRow{{
+		Conversation: row.Conversation,
+		UnreadCount:  row.UnreadCount,
+	}})
+	if err != nil {
+		return Conversation{}, err
+	}
+	return conversations[0], nil
+}
+
+// checkMessageBody enforces the length limit and runs the body through the
+// same moderation rules as chirps. There is no review queue for private
+// messages, so anything that would hold a chirp refuses the message.
+func (cfg *apiConfig) checkMessageBody(ctx context.Context, body string) (moderation.Result, error) {
+	if len(body) > maxMessageLength {
+		return moderation.Result{}, errMessageTooLong
+	}
+	result, err := cfg.moderateBody(ctx, body)
+	if err != nil {
+		return result, err
+	}
+	if result.Action == moderation.ActionReject || result.Action == moderation.ActionHold {
+		return result, errMessageRejected
+	}
+	return result, nil
+}
+
+// handlerCreateConversation starts a conversation between the caller and
+// participant_ids. If one already exists with exactly those participants it
+// is returned instead.
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ParticipantIDs []uuid.UUID `json:"participant_ids"`
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+
+	userIDs := []uuid.UUID{userID}
+	for _, id := range params.ParticipantIDs {
+		if !slices.Contains(userIDs, id) {
+			userIDs = append(userIDs, id)
+		}
+	}
+	if len(userIDs) < 2 || len(userIDs) > maxConversationParticipants {
+		respondWithError(w, http.StatusBadRequest, "a conversation needs between 1 and 19 other participants")
+		return
+	}
+	slices.SortFunc(userIDs, func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Requests for the same set of participants take turns, so two of them
+	// cannot both miss the lookup below and create the conversation twice.
+	err = qtx.LockConversationParticipants(r.Context(), userIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to lock conversation participants")
+		return
+	}
+
+	existing, err := qtx.FindConversationByParticipants(r.Context(), database.FindConversationByParticipantsParams{
+		UserID:  userID,
+		UserIds: userIDs,
+	})
+	if err == nil {
+		conversation, err := conversationForUser(r.Context(), qtx, existing.ID, userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, conversation)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+
+	created, err := qtx.CreateConversation(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+		return
+	}
+	added, err := qtx.AddConversationParticipants(r.Context(), database.AddConversationParticipantsParams{
+		ConversationID: created.ID,
+		UserIds:        userIDs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+		return
+	}
+	if added != int64(len(userIDs)) {
+		respondWithError(w, http.StatusNotFound, "failed to find user with id")
+		return
+	}
+
+	conversation, err := conversationForUser(r.Context(), qtx, created.ID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing conversation: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, conversation)
+}
+
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetConversationsParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorUpdatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetConversations(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversations")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(row database.GetConversationsRow) (time.Time, uuid.UUID) {
+		return row.Conversation.UpdatedAt, row.Conversation.ID
+	})
+
+	conversations, err := conversationsFromDB(r.Context(), cfg.db, rows)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversations")
+		return
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, conversationPage{
+		Conversations: conversations,
+		Next:          next,
+	})
+}
+
+func (cfg *apiConfig) handlerGetConversation(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse conversationID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	conversation, err := conversationForUser(r.Context(), cfg.db, conversationID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "failed to find conversation with id")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, conversation)
+}
+
+func (cfg *apiConfig) handlerSendMessage(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse conversationID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+	if params.Body == "" {
+		respondWithError(w, http.StatusBadRequest, "a message needs a body")
+		return
+	}
+
+	participant, err := cfg.db.IsConversationParticipant(r.Context(), database.IsConversationParticipantParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+	if !participant {
+		respondWithError(w, http.StatusNotFound, "failed to find conversation with id")
+		return
+	}
+
+	result, err := cfg.checkMessageBody(r.Context(), params.Body)
+	if errors.Is(err, errMessageTooLong) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, errMessageRejected) {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check message")
+		return
+	}
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	message, err := qtx.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Body:           result.Text,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to send message")
+		return
+	}
+	err = qtx.TouchConversation(r.Context(), database.TouchConversationParams{
+		ID:        conversationID,
+		UpdatedAt: message.CreatedAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to send message")
+		return
+	}
+	// Senders have read everything up to their own message.
+	err = markRead(r.Context(), qtx, userID, message)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to send message")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing message: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, messageFromDB(message))
+}
+
+func (cfg *apiConfig) handlerGetMessages(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse conversationID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	participant, err := cfg.db.IsConversationParticipant(r.Context(), database.IsConversationParticipantParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+	if !participant {
+		respondWithError(w, http.StatusNotFound, "failed to find conversation with id")
+		return
+	}
+
+	params := database.GetMessagesParams{
+		ConversationID: conversationID,
+		Limit:          page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetMessages(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get messages")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(message database.Message) (time.Time, uuid.UUID) {
+		return message.CreatedAt, message.ID
+	})
+
+	messages := []Message{}
+	for _, row := range rows {
+		messages = append(messages, messageFromDB(row))
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, messagePage{
+		Messages: messages,
+		Next:     next,
+	})
+}
+
+// handlerMarkConversationRead moves the caller's read marker up to
+// message_id, or to the latest message when it is left out. Markers never
+// move backwards.
+func (cfg *apiConfig) handlerMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MessageID *uuid.UUID `json:"message_id"`
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse conversationID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+
+	participant, err := cfg.db.IsConversationParticipant(r.Context(), database.IsConversationParticipantParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+	if !participant {
+		respondWithError(w, http.StatusNotFound, "failed to find conversation with id")
+		return
+	}
+
+	var message database.Message
+	if params.MessageID != nil {
+		message, err = cfg.db.GetMessage(r.Context(), database.GetMessageParams{
+			ID:             *params.MessageID,
+			ConversationID: conversationID,
+		})
+	} else {
+		message, err = cfg.db.GetLatestMessage(r.Context(), conversationID)
+	}
+	if errors.Is(err, sql.ErrNoRows) && params.MessageID != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find message with id")
+		return
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "failed to get message")
+		return
+	}
+
+	if err == nil {
+		err = markRead(r.Context(), cfg.db, userID, message)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to mark conversation read")
+			return
+		}
+	}
+
+	conversation, err := conversationForUser(r.Context(), cfg.db, conversationID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, conversation)
+}
+
+func markRead(ctx context.Context, q *database.Queries, userID uuid.UUID, message database.Message) error {
+	return q.MarkConversationRead(ctx, database.MarkConversationReadParams{
+		LastReadAt:        sql.NullTime{Time: message.CreatedAt, Valid: true},
+		LastReadMessageID: uuid.NullUUID{UUID: message.ID, Valid: true},
+		ConversationID:    message.ConversationID,
+		UserID:            userID,
+	})
+}
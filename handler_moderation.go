@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/moderation"
+	"github.com/google/uuid"
+)
+
+var errChirpRejected = errors.New("chirp breaks the content rules")
+
+type ModerationRule struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+}
+
+// HeldChirp is a chirp, or an edit to one when ChirpID is set, waiting for a
+// moderator before it is published.
+type HeldChirp struct {
+	ID         uuid.UUID   `json:"id"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UserID     uuid.UUID   `json:"user_id"`
+	ChirpID    *uuid.UUID  `json:"chirp_id,omitempty"`
+	Body       string      `json:"body"`
+	InReplyTo  *uuid.UUID  `json:"in_reply_to,omitempty"`
+	QuoteOf    *uuid.UUID  `json:"quote_of,omitempty"`
+	MediaIDs   []uuid.UUID `json:"media_ids"`
+	Visibility string      `json:"visibility"`
+	Poll       *pollParams `json:"poll,omitempty"`
+	Reason     string      `json:"reason"`
+}
+
+type heldChirpPage struct {
+	Held []HeldChirp `json:"held"`
+	Next string      `json:"next,omitempty"`
+}
+
+func heldChirpFromDB(held database.HeldChirp) HeldChirp {
+	resp := HeldChirp{
+		ID:         held.ID,
+		CreatedAt:  held.CreatedAt,
+		UserID:     held.UserID,
+		Body:       held.Body,
+		MediaIDs:   held.MediaIds,
+		Visibility: held.Visibility,
+		Reason:     held.Reason,
+	}
+	if held.ChirpID.Valid {
+		resp.ChirpID = &held.ChirpID.UUID
+	}
+	if held.InReplyTo.Valid {
+		resp.InReplyTo = &held.InReplyTo.UUID
+	}
+	if held.QuoteOf.Valid {
+		resp.QuoteOf = &held.QuoteOf.UUID
+	}
+	if poll := heldPoll(held); poll != nil {
+		resp.Poll = &pollParams{
+			Options:  poll.Options,
+			ClosesAt: poll.ClosesAt,
+		}
+	}
+	return resp
+}
+
+// heldPoll returns the poll a held chirp was submitted with, if any.
+func heldPoll(held database.HeldChirp) *newPoll {
+	if !held.PollClosesAt.Valid {
+		return nil
+	}
+	return &newPoll{
+		Options:  held.PollOptions,
+		ClosesAt: held.PollClosesAt.Time,
+	}
+}
+
+// moderationFilter combines the rules read from the word list file at
+// startup with the ones managed through the admin endpoints.
+func (cfg *apiConfig) moderationFilter(ctx context.Context) (moderation.Filter, error) {
+	rows, err := cfg.db.GetModerationRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := slices.Clone(cfg.moderationRules)
+	for _, row := range rows {
+		rules = append(rules, moderation.Rule{
+			Pattern: row.Pattern,
+			Action:  moderation.Action(row.Action),
+		})
+	}
+	return moderation.NewWordList(rules), nil
+}
+
+// holdChirp queues a submission for review, recording which rules sent it
+// there. chirpID is set when the submission is an edit.
+func holdChirp(ctx context.Context, q *database.Queries, submission newChirp, chirpID uuid.NullUUID, result moderation.Result) (database.HeldChirp, error) {
+	patterns := []string{}
+	for _, m := range result.Matches {
+		if m.Rule.Action == moderation.ActionHold && !slices.Contains(patterns, m.Rule.Pattern) {
+			patterns = append(patterns, m.Rule.Pattern)
+		}
+	}
+
+	mediaIDs := submission.MediaIDs
+	if mediaIDs == nil {
+		mediaIDs = []uuid.UUID{}
+	}
+	// Held edits are submitted with the visibility of the chirp they change,
+	// so the review queue shows who will be able to read them.
+	visibility := submission.Visibility
+	if visibility == "" {
+		visibility = visibilityPublic
+	}
+	pollOptions := []string{}
+	pollClosesAt := sql.NullTime{}
+	if submission.Poll != nil {
+		pollOptions = submission.Poll.Options
+		pollClosesAt = sql.NullTime{Time: submission.Poll.ClosesAt, Valid: true}
+	}
+
+	return q.CreateHeldChirp(ctx, database.CreateHeldChirpParams{
+		UserID:       submission.UserID,
+		ChirpID:      chirpID,
+		Body:         submission.Body,
+		InReplyTo:    submission.InReplyTo,
+		QuoteOf:      submission.QuoteOf,
+		Reason:       strings.Join(patterns, ", "),
+		MediaIds:     mediaIDs,
+		Visibility:   visibility,
+		PollOptions:  pollOptions,
+		PollClosesAt: pollClosesAt,
+	})
+}
+
+func (cfg *apiConfig) handlerGetModerationRules(w http.ResponseWriter, r *http.Request) {
+	rows, err := cfg.db.GetModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get moderation rules")
+		return
+	}
+
+	rules := []ModerationRule{}
+	for _, row := range rows {
+		rules = append(rules, ModerationRule{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			Pattern:   row.Pattern,
+			Action:    row.Action,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, rules)
+}
+
+func (cfg *apiConfig) handlerCreateModerationRule(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Pattern string `json:"pattern"`
+		Action  string `json:"action"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+
+	action, err := moderation.ParseAction(params.Action)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "action must be one of censor, hold or reject")
+		return
+	}
+	pattern := strings.Join(strings.Fields(params.Pattern), " ")
+	if moderation.Normalize(pattern) == "" {
+		respondWithError(w, http.StatusBadRequest, "pattern must contain a word")
+		return
+	}
+
+	row, err := cfg.db.CreateModerationRule(r.Context(), database.CreateModerationRuleParams{
+		Pattern: pattern,
+		Action:  string(action),
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "a rule for this pattern already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create moderation rule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ModerationRule{
+		ID:        row.ID,
+		CreatedAt: row.CreatedAt,
+		Pattern:   row.Pattern,
+		Action:    row.Action,
+	})
+}
+
+func (cfg *apiConfig) handlerDeleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse ruleID to uuid")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteModerationRule(r.Context(), ruleID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to delete moderation rule")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "failed to find moderation rule with id")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerGetHeldChirps(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetHeldChirpsParams{
+		Limit: page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetHeldChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get held chirps")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(held database.HeldChirp) (time.Time, uuid.UUID) {
+		return held.CreatedAt, held.ID
+	})
+
+	held := []HeldChirp{}
+	for _, row := range rows {
+		held = append(held, heldChirpFromDB(row))
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, heldChirpPage{
+		Held: held,
+		Next: next,
+	})
+}
+
+// handlerApproveHeldChirp publishes a held chirp, or applies a held edit, as
+// if it had passed moderation in the first place. A held poll whose closing
+// time is too close or already past by the time it is approved needs a new
+// poll_closes_at.
+func (cfg *apiConfig) handlerApproveHeldChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		PollClosesAt *time.Time `json:"poll_closes_at"`
+	}
+
+	heldID, err := uuid.Parse(r.PathValue("heldID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse heldID to uuid")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "failed to decode parameters")
+		return
+	}
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	held, err := qtx.GetHeldChirpForUpdate(r.Context(), heldID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find held chirp with id")
+		return
+	}
+
+	var chirp database.Chirp
+	status := http.StatusCreated
+	if held.ChirpID.Valid {
+		chirp, err = qtx.GetChirpByIdForUpdate(r.Context(), held.ChirpID.UUID)
+		if err != nil || chirpRemoved(chirp) {
+			respondWithError(w, http.StatusNotFound, "the edited chirp no longer exists")
+			return
+		}
+		chirp, err = editChirp(r.Context(), qtx, chirp, held.Body)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+			return
+		}
+		status = http.StatusOK
+	} else {
+		poll := heldPoll(held)
+		if poll != nil && params.PollClosesAt != nil {
+			poll.ClosesAt, err = checkPollClosesAt(*params.PollClosesAt)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "poll_closes_at must be between 5 minutes and 7 days from now")
+				return
+			}
+		} else if poll != nil {
+			_, err = checkPollClosesAt(poll.ClosesAt)
+			if err != nil {
+				respondWithError(w, http.StatusConflict, "the held poll would close too soon; approve it with a new poll_closes_at")
+				return
+			}
+		}
+		chirp, err = insertChirp(r.Context(), qtx, newChirp{
+			Body:       held.Body,
+			UserID:     held.UserID,
+			InReplyTo:  held.InReplyTo,
+			QuoteOf:    held.QuoteOf,
+			MediaIDs:   held.MediaIds,
+			Visibility: held.Visibility,
+			Poll:       poll,
+		})
+		if err != nil {
+			respondWithInsertError(w, err)
+			return
+		}
+	}
+
+	_, err = qtx.DeleteHeldChirp(r.Context(), held.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to remove held chirp")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing held chirp approval: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, status, chirps[0])
+}
+
+func (cfg *apiConfig) handlerDiscardHeldChirp(w http.ResponseWriter, r *http.Request) {
+	heldID, err := uuid.Parse(r.PathValue("heldID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse heldID to uuid")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteHeldChirp(r.Context(), heldID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to discard held chirp")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "failed to find held chirp with id")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const minPollOptions = 2
+const maxPollOptions = 4
+const maxPollOptionLength = 50
+const minPollDuration = 5 * time.Minute
+const maxPollDuration = 7 * 24 * time.Hour
+
+// Poll is the poll attached to a chirp. Tallies are left out until the
+// viewer has voted or the poll has closed, so early results cannot sway
+// anyone.
+type Poll struct {
+	ClosesAt   time.Time    `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	Options    []PollOption `json:"options"`
+	TotalVotes *int32       `json:"total_votes,omitempty"`
+	MyVote     *int32       `json:"my_vote,omitempty"`
+}
+
+type PollOption struct {
+	Position int32  `json:"position"`
+	Text     string `json:"text"`
+	Votes    *int32 `json:"votes,omitempty"`
+}
+
+type pollParams struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type newPoll struct {
+	Options  []string
+	ClosesAt time.Time
+}
+
+// parsePoll checks the poll a new chirp asks to carry.
+func parsePoll(params pollParams) (*newPoll, error) {
+	if len(params.Options) < minPollOptions || len(params.Options) > maxPollOptions {
+		return nil, errors.New("a poll must have between 2 and 4 options")
+	}
+
+	options := []string{}
+	seen := map[string]bool{}
+	for _, option := range params.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength {
+			return nil, errors.New("poll options must be between 1 and 50 characters")
+		}
+		if seen[strings.ToLower(option)] {
+			return nil, errors.New("poll options must be different")
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+
+	closesAt, err := checkPollClosesAt(params.ClosesAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newPoll{Options: options, ClosesAt: closesAt}, nil
+}
+
+// checkPollClosesAt makes sure a poll stays open long enough to vote in, but
+// not indefinitely.
+func checkPollClosesAt(closesAt time.Time) (time.Time, error) {
+	now := time.Now().UTC()
+	closesAt = closesAt.UTC()
+	if closesAt.Before(now.Add(minPollDuration)) || closesAt.After(now.Add(maxPollDuration)) {
+		return closesAt, errors.New("closes_at must be between 5 minutes and 7 days from now")
+	}
+	return closesAt, nil
+}
+
+// moderatePoll runs each option through the moderation rules, censoring
+// them in place, and folds what it found into the result for the body.
+func (cfg *apiConfig) moderatePoll(ctx context.Context, poll *newPoll, result moderation.Result) (moderation.Result, error) {
+	filter, err := cfg.moderationFilter(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for i, option := range poll.Options {
+		checked := moderation.Check(option, filter)
+		poll.Options[i] = checked.Text
+		result.Matches = append(result.Matches, checked.Matches...)
+		if checked.Action.MoreSevere(result.Action) {
+			result.Action = checked.Action
+		}
+	}
+	return result, nil
+}
+
+func createPoll(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, poll *newPoll) error {
+	err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirpID,
+		ClosesAt: poll.ClosesAt,
+	})
+	if err != nil {
+		return err
+	}
+	return qtx.CreatePollOptions(ctx, database.CreatePollOptionsParams{
+		ChirpID: chirpID,
+		Options: poll.Options,
+	})
+}
+
+// pollFromDB builds the poll for one chirp from its option rows. myVote is
+// the viewer's choice, if they voted.
+func pollFromDB(rows []database.GetPollsForChirpsRow, myVote *int32, now time.Time) *Poll {
+	poll := &Poll{
+		ClosesAt: rows[0].ClosesAt,
+		Closed:   !now.Before(rows[0].ClosesAt),
+		Options:  []PollOption{},
+		MyVote:   myVote,
+	}
+
+	showTallies := poll.Closed || myVote != nil
+	total := int32(0)
+	for _, row := range rows {
+		option := PollOption{
+			Position: row.Position,
+			Text:     row.Text,
+		}
+		if showTallies {
+			votes := row.VoteCount
+			option.Votes = &votes
+		}
+		total += row.VoteCount
+		poll.Options = append(poll.Options, option)
+	}
+	if showTallies {
+		poll.TotalVotes = &total
+	}
+	return poll
+}
+
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Option *int32 `json:"option"`
+	}
+
+	chirpUuid, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse chirpID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil || params.Option == nil {
+		respondWithError(w, http.StatusBadRequest, "option must be set to the position of a poll option")
+		return
+	}
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpById(r.Context(), chirpUuid)
+	if err != nil || chirpRemoved(chirp) {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+	visible, err := chirpVisibleTo(r.Context(), qtx, uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to check chirp visibility")
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp with id")
+		return
+	}
+
+	poll, err := qtx.GetPollForUpdate(r.Context(), chirp.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "chirp has no poll")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get poll")
+		return
+	}
+	if !time.Now().UTC().Before(poll.ClosesAt) {
+		respondWithError(w, http.StatusConflict, "poll is closed")
+		return
+	}
+
+	options, err := qtx.CountPollOptions(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get poll")
+		return
+	}
+	if *params.Option < 0 || int64(*params.Option) >= options {
+		respondWithError(w, http.StatusBadRequest, "option must be the position of a poll option")
+		return
+	}
+
+	voted, err := qtx.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  chirp.ID,
+		UserID:   userID,
+		Position: *params.Option,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to record vote")
+		return
+	}
+	if voted == 0 {
+		respondWithError(w, http.StatusConflict, "you have already voted in this poll")
+		return
+	}
+
+	err = qtx.IncrementPollVoteCount(r.Context(), database.IncrementPollVoteCountParams{
+		ChirpID:  chirp.ID,
+		Position: *params.Option,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to record vote")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing poll vote: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirps[0])
+}
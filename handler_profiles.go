@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxDisplayNameLength = 50
+const maxBioLength = 160
+const maxAvatarURLLength = 2048
+
+// UserProfile is the public view of a user. It never carries the email or
+// anything else only the user themselves should see.
+type UserProfile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Username       string    `json:"username,omitempty"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int32     `json:"follower_count"`
+	FollowingCount int32     `json:"following_count"`
+}
+
+func (cfg *apiConfig) userProfile(ctx context.Context, user database.User) (UserProfile, error) {
+	chirpCount, err := cfg.db.CountUserChirps(ctx, user.ID)
+	if err != nil {
+		return UserProfile{}, err
+	}
+	return UserProfile{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		Username:       user.Username.String,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		AvatarURL:      user.AvatarUrl,
+		IsChirpyRed:    user.IsChirpyRed,
+		ChirpCount:     chirpCount,
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+	}, nil
+}
+
+// validateProfile checks the optional profile fields of a user update.
+func validateProfile(displayName, bio, avatarURL *string) error {
+	if displayName != nil && utf8.RuneCountInString(*displayName) > maxDisplayNameLength {
+		return errors.New("display name is too long")
+	}
+	if bio != nil && utf8.RuneCountInString(*bio) > maxBioLength {
+		return errors.New("bio is too long")
+	}
+	if avatarURL != nil && *avatarURL != "" {
+		u, err := url.Parse(*avatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(*avatarURL) > maxAvatarURLLength {
+			return errors.New("avatar url must be an absolute http or https url")
+		}
+	}
+	return nil
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func (cfg *apiConfig) handlerGetUserProfile(w http.ResponseWriter, r *http.Request) {
+	idOrUsername := r.PathValue("idOrUsername")
+
+	var user database.User
+	var err error
+	if id, parseErr := uuid.Parse(idOrUsername); parseErr == nil {
+		user, err = cfg.db.GetUserByID(r.Context(), id)
+	} else {
+		user, err = cfg.db.GetUserByUsername(r.Context(), strings.TrimPrefix(idOrUsername, "@"))
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find user")
+		return
+	}
+
+	profile, err := cfg.userProfile(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load profile")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profile)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+var errChirpUnavailable = errors.New("chirp is not available")
+
+// resolveRechirpTarget locks the chirp being rechirped or quoted. Pointing at
+// a plain rechirp resolves to its original so chains never form.
+func resolveRechirpTarget(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := qtx.GetChirpByIdForUpdate(ctx, chirpID)
+	if err != nil {
+		return chirp, err
+	}
+	if chirp.RechirpOf.Valid {
+		chirp, err = qtx.GetChirpByIdForUpdate(ctx, chirp.RechirpOf.UUID)
+		if err != nil {
+			return chirp, err
+		}
+	}
+	if chirpRemoved(chirp) || !canRechirp(chirp) {
+		return chirp, errChirpUnavailable
+	}
+	return chirp, nil
+}
+
+func (cfg *apiConfig) createRechirp(w http.ResponseWriter, r *http.Request, userID, chirpID uuid.UUID) {
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	original, err := resolveRechirpTarget(r.Context(), qtx, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "failed to find chirp to rechirp")
+		return
+	}
+
+	rechirp, err := qtx.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:     userID,
+		RechirpOf:  uuid.NullUUID{UUID: original.ID, Valid: true},
+		Visibility: original.Visibility,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "chirp already rechirped")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create rechirp")
+		return
+	}
+
+	err = qtx.IncrementRechirpCount(r.Context(), original.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update rechirped chirp")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing rechirp: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r, []database.Chirp{rechirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load viewer state")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirps[0])
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+const scheduledPublishInterval = 10 * time.Second
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+type ScheduledChirp struct {
+	ID         uuid.UUID   `json:"id"`
+	CreatedAt  time.Time   `json:"created_at"`
+	PublishAt  time.Time   `json:"publish_at"`
+	Body       string      `json:"body"`
+	InReplyTo  *uuid.UUID  `json:"in_reply_to,omitempty"`
+	QuoteOf    *uuid.UUID  `json:"quote_of,omitempty"`
+	MediaIDs   []uuid.UUID `json:"media_ids"`
+	Visibility string      `json:"visibility"`
+	Failure    string      `json:"failure,omitempty"`
+}
+
+type scheduledChirpPage struct {
+	Scheduled []ScheduledChirp `json:"scheduled"`
+	Next      string           `json:"next,omitempty"`
+}
+
+func scheduledChirpFromDB(scheduled database.ScheduledChirp) ScheduledChirp {
+	resp := ScheduledChirp{
+		ID:         scheduled.ID,
+		CreatedAt:  scheduled.CreatedAt,
+		PublishAt:  scheduled.PublishAt,
+		Body:       scheduled.Body,
+		MediaIDs:   scheduled.MediaIds,
+		Visibility: scheduled.Visibility,
+		Failure:    scheduled.Failure,
+	}
+	if scheduled.InReplyTo.Valid {
+		resp.InReplyTo = &scheduled.InReplyTo.UUID
+	}
+	if scheduled.QuoteOf.Valid {
+		resp.QuoteOf = &scheduled.QuoteOf.UUID
+	}
+	return resp
+}
+
+// scheduleChirp stores a submission that has already passed moderation to
+// be published at publishAt. Its references are checked now so the client
+// hears about a bad one straight away; publishing checks them again, since
+// they can go away in the meantime.
+func (cfg *apiConfig) scheduleChirp(w http.ResponseWriter, r *http.Request, submission newChirp, publishAt time.Time) {
+	now := time.Now().UTC()
+	publishAt = publishAt.UTC()
+	if !publishAt.After(now) {
+		respondWithError(w, http.StatusBadRequest, "publish_at must be in the future")
+		return
+	}
+	if publishAt.After(now.Add(maxScheduleAhead)) {
+		respondWithError(w, http.StatusBadRequest, "publish_at must be within a year")
+		return
+	}
+
+	submission, err := checkChirpReferences(r.Context(), cfg.db, submission)
+	if err != nil {
+		respondWithInsertError(w, err)
+		return
+	}
+
+	scheduled, err := cfg.db.CreateScheduledChirp(r.Context(), database.CreateScheduledChirpParams{
+		UserID:     submission.UserID,
+		Body:       submission.Body,
+		InReplyTo:  submission.InReplyTo,
+		QuoteOf:    submission.QuoteOf,
+		MediaIds:   submission.MediaIDs,
+		PublishAt:  publishAt,
+		Visibility: submission.Visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to schedule chirp")
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, scheduledChirpFromDB(scheduled))
+}
+
+func (cfg *apiConfig) handlerGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil || page.backward() {
+		respondWithError(w, http.StatusBadRequest, "invalid limit or cursor")
+		return
+	}
+
+	params := database.GetScheduledChirpsParams{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+	}
+	if page.cursor != nil {
+		params.CursorPublishAt = sql.NullTime{Time: page.cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetScheduledChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get scheduled chirps")
+		return
+	}
+	rows, next, _ := buildPage(rows, page, func(scheduled database.ScheduledChirp) (time.Time, uuid.UUID) {
+		return scheduled.PublishAt, scheduled.ID
+	})
+
+	scheduled := []ScheduledChirp{}
+	for _, row := range rows {
+		scheduled = append(scheduled, scheduledChirpFromDB(row))
+	}
+
+	setLinkHeader(w, r, next, "")
+	respondWithJSON(w, http.StatusOK, scheduledChirpPage{
+		Scheduled: scheduled,
+		Next:      next,
+	})
+}
+
+// handlerDeleteScheduledChirp cancels one scheduled chirp, named by the id
+// query parameter. A path parameter would collide with the routes under
+// /api/chirps/{chirpID}/.
+func (cfg *apiConfig) handlerDeleteScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	scheduledID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse id to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	deleted, err := cfg.db.DeleteScheduledChirp(r.Context(), database.DeleteScheduledChirpParams{
+		ID:     scheduledID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to delete scheduled chirp")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "failed to find scheduled chirp with id")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// runScheduledPublisher publishes due scheduled chirps until ctx is done.
+func (cfg *apiConfig) runScheduledPublisher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := cfg.publishNextScheduledChirp(ctx)
+			if err != nil {
+				log.Printf("Error publishing scheduled chirp: %s", err)
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishNextScheduledChirp publishes the oldest due scheduled chirp, if
+// there is one. The row is claimed with FOR UPDATE SKIP LOCKED and deleted
+// in the transaction that creates the chirp, so when several servers poll
+// the same database each scheduled chirp is published exactly once.
+func (cfg *apiConfig) publishNextScheduledChirp(ctx context.Context) (bool, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	scheduled, err := qtx.ClaimDueScheduledChirp(ctx, time.Now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = insertChirp(ctx, qtx, newChirp{
+		Body:       scheduled.Body,
+		UserID:     scheduled.UserID,
+		InReplyTo:  scheduled.InReplyTo,
+		QuoteOf:    scheduled.QuoteOf,
+		MediaIDs:   scheduled.MediaIds,
+		Visibility: scheduled.Visibility,
+	})
+	if isPermanentInsertError(err) {
+		// The chirp it replies to or quotes has gone, or its media was used
+		// elsewhere. Keep it for the owner to see instead of retrying.
+		tx.Rollback()
+		return true, cfg.db.MarkScheduledChirpFailed(ctx, database.MarkScheduledChirpFailedParams{
+			ID:      scheduled.ID,
+			Failure: err.Error(),
+		})
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = qtx.DeleteScheduledChirp(ctx, database.DeleteScheduledChirpParams{
+		ID:     scheduled.ID,
+		UserID: scheduled.UserID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func isPermanentInsertError(err error) bool {
+	return errors.Is(err, errReplyTargetNotFound) || errors.Is(err, errQuoteTargetNotFound) ||
+		errors.Is(err, errQuoteNeedsBody) || errors.Is(err, errInvalidMedia)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/auth"
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+// A session is one login: the family of refresh tokens it started, which
+// rotate on every refresh. Revoking a session stops it from refreshing, but
+// access tokens already handed out keep working until they expire, at most
+// an hour later.
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
+
+// clientFor describes the device making a request. The IP is the peer
+// address; forwarding headers are not trusted.
+func clientFor(r *http.Request) auth.Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return auth.Client{
+		UserAgent: r.UserAgent(),
+		IP:        ip,
+	}
+}
+
+func (cfg *apiConfig) handlerGetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+	current := r.Context().Value("sessionID").(uuid.NullUUID)
+
+	rows, err := cfg.db.GetSessions(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get sessions")
+		return
+	}
+
+	sessions := []Session{}
+	for _, row := range rows {
+		sessions = append(sessions, Session{
+			ID:         row.FamilyID,
+			CreatedAt:  row.StartedAt,
+			LastUsedAt: row.LastUsedAt,
+			ExpiresAt:  row.ExpiresAt,
+			UserAgent:  row.UserAgent,
+			IP:         row.Ip,
+			Current:    current.Valid && current.UUID == row.FamilyID,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+func (cfg *apiConfig) handlerRevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionUuid, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to parse sessionID to uuid")
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	revoked, err := cfg.db.RevokeUserRefreshTokenFamily(r.Context(), database.RevokeUserRefreshTokenFamilyParams{
+		FamilyID: sessionUuid,
+		UserID:   userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "failed to find session with id")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// handlerRevokeOtherSessions logs the user out everywhere except the session
+// making the request. Access tokens issued before sessions were tracked do
+// not say which session is theirs, so they cannot use it.
+func (cfg *apiConfig) handlerRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+	current := r.Context().Value("sessionID").(uuid.NullUUID)
+	if !current.Valid {
+		respondWithError(w, http.StatusBadRequest, "access token has no session; refresh it and try again")
+		return
+	}
+
+	_, err := cfg.db.RevokeOtherRefreshTokenFamilies(r.Context(), database.RevokeOtherRefreshTokenFamiliesParams{
+		UserID:       userID,
+		KeepFamilyID: current.UUID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -1,18 +1,51 @@
 package auth
 
 import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newKeySet(t *testing.T, signing crypto.Signer, previous ...crypto.PublicKey) *KeySet {
+	t.Helper()
+	keys, err := NewKeySet(signing, previous...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
 func TestGetBearerToken(t *testing.T) {
 	userId1 := uuid.New()
-	validToken, _ := MakeJWT(userId1, "secret", time.Hour)
+	keys := newKeySet(t, newEd25519Key(t))
+	validToken, _ := MakeJWT(userId1, uuid.New(), keys, time.Hour)
 	header := http.Header{}
-	header.Set("Authorization", validToken)
+	header.Set("Authorization", "Bearer "+validToken)
 
 	tests := []struct {
 		name    string
@@ -39,27 +72,79 @@ func TestGetBearerToken(t *testing.T) {
 
 func TestCreateAndValidateJWT(t *testing.T) {
 	userId1 := uuid.New()
-	validToken, _ := MakeJWT(userId1, "secret", time.Hour)
+
+	oldKey := newEd25519Key(t)
+	newKey := newEd25519Key(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKeys := newKeySet(t, oldKey)
+	rotatedKeys := newKeySet(t, newKey, oldKey.Public())
+	rsaKeys := newKeySet(t, rsaKey)
+
+	oldToken, _ := MakeJWT(userId1, uuid.New(), oldKeys, time.Hour)
+	rsaToken, _ := MakeJWT(userId1, uuid.New(), rsaKeys, time.Hour)
+	expiredToken, _ := MakeJWT(userId1, uuid.New(), rotatedKeys, -time.Minute)
+
+	// A token signed with the shared secret scheme, naming a trusted key.
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userId1.String(),
+	})
+	hmac.Header["kid"] = rotatedKeys.signing.id
+	hmacToken, _ := hmac.SignedString([]byte("secret"))
 
 	tests := []struct {
 		name        string
 		tokenString string
-		tokenSecret string
+		keys        *KeySet
 		wantUserID  uuid.UUID
 		wantErr     bool
 	}{
 		{
 			name:        "Valid token",
-			tokenString: validToken,
-			tokenSecret: "secret",
+			tokenString: oldToken,
+			keys:        oldKeys,
 			wantUserID:  userId1,
-			wantErr:     false,
+		},
+		{
+			name:        "Valid RS256 token",
+			tokenString: rsaToken,
+			keys:        rsaKeys,
+			wantUserID:  userId1,
+		},
+		{
+			name:        "Token from before a rotation",
+			tokenString: oldToken,
+			keys:        rotatedKeys,
+			wantUserID:  userId1,
+		},
+		{
+			name:        "Key no longer trusted",
+			tokenString: oldToken,
+			keys:        newKeySet(t, newKey),
+			wantErr:     true,
+		},
+		{
+			name:        "Expired token",
+			tokenString: expiredToken,
+			keys:        rotatedKeys,
+			wantErr:     true,
+		},
+		{
+			name:        "HS256 token",
+			tokenString: hmacToken,
+			keys:        rotatedKeys,
+			wantErr:     true,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tc.tokenString, tc.tokenSecret)
+			gotUserID, err := ValidateJWT(tc.tokenString, tc.keys)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tc.wantErr)
 				return
@@ -70,3 +155,348 @@ func TestCreateAndValidateJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAccessTokenSession(t *testing.T) {
+	keys := newKeySet(t, newEd25519Key(t))
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	withSession, _ := MakeJWT(userID, sessionID, keys, time.Hour)
+
+	// A token from before sessions were tracked has no sid claim.
+	legacy := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	})
+	legacy.Header["kid"] = keys.signing.id
+	legacyToken, _ := legacy.SignedString(keys.signingKey)
+
+	tests := []struct {
+		name        string
+		tokenString string
+		want        uuid.NullUUID
+	}{
+		{
+			name:        "Token with a session",
+			tokenString: withSession,
+			want:        uuid.NullUUID{UUID: sessionID, Valid: true},
+		},
+		{
+			name:        "Token without a session",
+			tokenString: legacyToken,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseAccessToken(tc.tokenString, keys)
+			if err != nil {
+				t.Fatalf("ParseAccessToken() error = %v", err)
+			}
+			if got.UserID != userID {
+				t.Errorf("ParseAccessToken() UserID = %v, want %v", got.UserID, userID)
+			}
+			if got.SessionID != tc.want {
+				t.Errorf("ParseAccessToken() SessionID = %v, want %v", got.SessionID, tc.want)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	oldKey := newEd25519Key(t)
+	newKey := newEd25519Key(t)
+	keys := newKeySet(t, newKey, oldKey.Public(), newKey.Public())
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() has %d keys, want 2", len(jwks.Keys))
+	}
+	if jwks.Keys[0].Kid != keys.signing.id {
+		t.Errorf("JWKS() first kid = %q, want signing key %q", jwks.Keys[0].Kid, keys.signing.id)
+	}
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.Alg != "EdDSA" || jwk.X == "" {
+			t.Errorf("JWKS() key = %+v, want an Ed25519 EdDSA key", jwk)
+		}
+	}
+}
+
+// recordingConn is a database/sql driver connection that records every
+// statement and argument it is sent. Queries answer with a single
+// refresh_tokens row whose hash is the first argument.
+type recordingConn struct {
+	calls []string
+}
+
+func (c *recordingConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *recordingConn) Driver() driver.Driver                        { return nil }
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare not supported")
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("begin not supported") }
+
+func (c *recordingConn) record(query string, args []driver.NamedValue) {
+	call := query
+	for _, arg := range args {
+		call += fmt.Sprintf(" %v", arg.Value)
+	}
+	c.calls = append(c.calls, call)
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	now := time.Now().UTC()
+	return &refreshTokenRows{values: []driver.Value{
+		args[0].Value, now, now, uuid.NewString(), now.Add(time.Hour), nil, uuid.NewString(), nil, nil, "agent", "127.0.0.1", now,
+	}}, nil
+}
+
+type refreshTokenRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *refreshTokenRows) Columns() []string {
+	return []string{"token_hash", "created_at", "updated_at", "user_id", "expires_at", "revoked_at", "family_id", "retired_at", "replaced_by", "user_agent", "ip", "last_used_at"}
+}
+func (r *refreshTokenRows) Close() error { return nil }
+func (r *refreshTokenRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestRefreshTokensNeverStorePlaintext(t *testing.T) {
+	rt, err := NewRefreshTokens([]byte(strings.Repeat("k", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &recordingConn{}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+	q := database.New(db)
+	ctx := context.Background()
+
+	first, err := rt.Issue(ctx, q, uuid.New(), uuid.New(), time.Now().Add(time.Hour), Client{})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	stored, err := rt.GetForUpdate(ctx, q, first)
+	if err != nil {
+		t.Fatalf("GetForUpdate() error = %v", err)
+	}
+	if stored.TokenHash != rt.Hash(first) {
+		t.Errorf("GetForUpdate() looked up %q, want the hash %q", stored.TokenHash, rt.Hash(first))
+	}
+	second, err := rt.Rotate(ctx, q, stored, time.Now().Add(time.Hour), Client{})
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	_, err = rt.Get(ctx, q, second)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if len(conn.calls) != 5 {
+		t.Fatalf("recorded %d statements, want 5", len(conn.calls))
+	}
+	for _, call := range conn.calls {
+		for _, token := range []string{first, second} {
+			if strings.Contains(call, token) {
+				t.Errorf("plaintext token sent to the database: %s", call)
+			}
+		}
+	}
+	if !strings.Contains(conn.calls[3], rt.Hash(second)) {
+		t.Errorf("RetireRefreshToken should record the successor's hash: %s", conn.calls[3])
+	}
+}
+
+func TestRefreshTokenHash(t *testing.T) {
+	key := []byte(strings.Repeat("a", 32))
+	rt, _ := NewRefreshTokens(key)
+	other, _ := NewRefreshTokens([]byte(strings.Repeat("b", 32)))
+
+	if rt.Hash("token") != rt.Hash("token") {
+		t.Error("Hash() is not deterministic")
+	}
+	if rt.Hash("token") == other.Hash("token") {
+		t.Error("Hash() does not depend on the key")
+	}
+	if rt.Hash("token") == rt.Hash("other") {
+		t.Error("Hash() gave two tokens the same hash")
+	}
+	if _, err := NewRefreshTokens([]byte("short")); err == nil {
+		t.Error("NewRefreshTokens() accepted a short key")
+	}
+}
+
+func TestPasswordHashing(t *testing.T) {
+	argon := DefaultPasswordHasher()
+	strongerArgon := DefaultPasswordHasher()
+	strongerArgon.Iterations = 3
+	bcrypter := &PasswordHasher{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost}
+
+	argonHash, err := argon.Hash("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcrypter.Hash("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// What HashPassword used to store.
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		password     string
+		hash         string
+		hasher       *PasswordHasher
+		wantErr      bool
+		wantRehash   bool
+		wantPrefix   string
+		wantMismatch bool
+	}{
+		{
+			name:       "Argon2id hash",
+			password:   "hunter2",
+			hash:       argonHash,
+			hasher:     argon,
+			wantPrefix: "$argon2id$v=19$m=19456,t=2,p=1$",
+		},
+		{
+			name:         "Wrong password for argon2id",
+			password:     "hunter3",
+			hash:         argonHash,
+			hasher:       argon,
+			wantErr:      true,
+			wantMismatch: true,
+		},
+		{
+			name:       "Argon2id hash with weaker parameters",
+			password:   "hunter2",
+			hash:       argonHash,
+			hasher:     strongerArgon,
+			wantRehash: true,
+		},
+		{
+			name:     "Bcrypt hash",
+			password: "hunter2",
+			hash:     bcryptHash,
+			hasher:   bcrypter,
+		},
+		{
+			name:         "Wrong password for bcrypt",
+			password:     "hunter3",
+			hash:         bcryptHash,
+			hasher:       bcrypter,
+			wantErr:      true,
+			wantMismatch: true,
+		},
+		{
+			name:       "Legacy bcrypt hash",
+			password:   "hunter2",
+			hash:       string(legacyHash),
+			hasher:     argon,
+			wantRehash: true,
+		},
+		{
+			name:       "Unsupported format",
+			password:   "unset",
+			hash:       "unset",
+			hasher:     argon,
+			wantErr:    true,
+			wantRehash: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckPasswordHash(tc.password, tc.hash)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("CheckPasswordHash() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if errors.Is(err, ErrPasswordMismatch) != tc.wantMismatch {
+				t.Errorf("CheckPasswordHash() error = %v, want mismatch %v", err, tc.wantMismatch)
+			}
+			if got := tc.hasher.NeedsRehash(tc.hash); got != tc.wantRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tc.wantRehash)
+			}
+			if !strings.HasPrefix(tc.hash, tc.wantPrefix) {
+				t.Errorf("hash = %q, want prefix %q", tc.hash, tc.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestParsePasswordHasher(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    PasswordHasher
+		wantErr bool
+	}{
+		{
+			name: "Default",
+			spec: "",
+			want: *DefaultPasswordHasher(),
+		},
+		{
+			name: "Argon2id parameters",
+			spec: "argon2id,m=65536,t=3,p=2",
+			want: PasswordHasher{Algorithm: PasswordArgon2id, Memory: 65536, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32, BcryptCost: 12},
+		},
+		{
+			name: "Bcrypt cost",
+			spec: "bcrypt,cost=13",
+			want: PasswordHasher{Algorithm: PasswordBcrypt, Memory: 19456, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32, BcryptCost: 13},
+		},
+		{
+			name:    "Argon2id below the minimum",
+			spec:    "argon2id,m=1024",
+			wantErr: true,
+		},
+		{
+			name:    "Bcrypt below the minimum",
+			spec:    "bcrypt,cost=4",
+			wantErr: true,
+		},
+		{
+			name:    "Parameter for the other algorithm",
+			spec:    "bcrypt,m=65536",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown algorithm",
+			spec:    "scrypt",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePasswordHasher(tc.spec)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParsePasswordHasher() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil && *got != tc.want {
+				t.Errorf("ParsePasswordHasher() = %+v, want %+v", *got, tc.want)
+			}
+		})
+	}
+}
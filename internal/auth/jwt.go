@@ -48,44 +48,83 @@ func GetBearerToken(headers http.Header) (string, error) {
 	return token, nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	sigendKey := []byte(tokenSecret)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
-	})
+// accessClaims are the claims in an access token. SessionID names the
+// refresh token family the token was issued from.
+type accessClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+}
 
-	return token.SignedString(sigendKey)
+// AccessToken is what a valid access token says about its bearer.
+type AccessToken struct {
+	UserID uuid.UUID
+	// SessionID is not set for tokens issued before sessions were tracked.
+	SessionID uuid.NullUUID
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claimsStruct := jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, &claimsStruct, func(t *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
+// MakeJWT issues an access token for userID in the given session, signed
+// with the key set's current signing key and naming it in the kid header.
+func MakeJWT(userID, sessionID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	token := jwt.NewWithClaims(keys.signing.method, accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    string(TokenTypeAccess),
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+		SessionID: sessionID.String(),
 	})
+	token.Header["kid"] = keys.signing.id
+
+	return token.SignedString(keys.signingKey)
+}
+
+// ValidateJWT checks an access token against every key in the set and
+// returns the user it was issued to.
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	token, err := ParseAccessToken(tokenString, keys)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	return token.UserID, nil
+}
+
+// ParseAccessToken checks an access token like ValidateJWT and also returns
+// the session it belongs to.
+func ParseAccessToken(tokenString string, keys *KeySet) (AccessToken, error) {
+	claimsStruct := accessClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, &claimsStruct, keys.keyFor,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+	)
+	if err != nil {
+		return AccessToken{}, err
+	}
 
 	idString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessToken{}, err
 	}
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessToken{}, err
 	}
 	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+		return AccessToken{}, errors.New("invalid issuer")
 	}
 
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return AccessToken{}, fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	return id, nil
+	access := AccessToken{UserID: id}
+	if claimsStruct.SessionID != "" {
+		sessionID, err := uuid.Parse(claimsStruct.SessionID)
+		if err != nil {
+			return AccessToken{}, fmt.Errorf("invalid session ID: %w", err)
+		}
+		access.SessionID = uuid.NullUUID{UUID: sessionID, Valid: true}
+	}
 
+	return access, nil
 }
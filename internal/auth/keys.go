@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// verificationKey is a public key tokens may be signed with, along with the
+// algorithm it is used with and its key ID.
+type verificationKey struct {
+	id     string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// KeySet signs access tokens with one private key and verifies them against
+// any of several public keys. Keeping the previous keys in the set while a
+// new one takes over lets tokens issued before a rotation stay valid until
+// they expire.
+type KeySet struct {
+	signingKey crypto.Signer
+	signing    verificationKey
+	keys       map[string]verificationKey
+	order      []string
+}
+
+// NewKeySet builds a key set that signs with signingKey and also accepts
+// tokens signed by the private halves of previous. Ed25519 keys sign with
+// EdDSA and RSA keys with RS256.
+func NewKeySet(signingKey crypto.Signer, previous ...crypto.PublicKey) (*KeySet, error) {
+	signing, err := newVerificationKey(signingKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{
+		signingKey: signingKey,
+		signing:    signing,
+		keys:       map[string]verificationKey{signing.id: signing},
+		order:      []string{signing.id},
+	}
+	for _, public := range previous {
+		key, err := newVerificationKey(public)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ks.keys[key.id]; ok {
+			continue
+		}
+		ks.keys[key.id] = key
+		ks.order = append(ks.order, key.id)
+	}
+	return ks, nil
+}
+
+// LoadKeySet reads the signing key from the PEM file at signingPath and any
+// previous keys from verifyPaths. Previous keys may be given as public or
+// private key PEM files.
+func LoadKeySet(signingPath string, verifyPaths ...string) (*KeySet, error) {
+	data, err := os.ReadFile(signingPath)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signingPath, err)
+	}
+
+	previous := []crypto.PublicKey{}
+	for _, path := range verifyPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		public, err := ParsePublicKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		previous = append(previous, public)
+	}
+
+	return NewKeySet(signingKey, previous...)
+}
+
+// ParsePrivateKeyPEM parses an Ed25519 or RSA private key in PKCS #8 form, or
+// an RSA key in PKCS #1 form.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	}
+	return nil, errors.New("private key must be Ed25519 or RSA")
+}
+
+// ParsePublicKeyPEM parses an Ed25519 or RSA public key. A private key PEM
+// is accepted too, in which case its public half is returned.
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	private, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return private.Public(), nil
+}
+
+func newVerificationKey(public crypto.PublicKey) (verificationKey, error) {
+	var method jwt.SigningMethod
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return verificationKey{}, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		method = jwt.SigningMethodRS256
+	default:
+		return verificationKey{}, errors.New("key must be Ed25519 or RSA")
+	}
+
+	jwk := publicJWK(public)
+	id, err := thumbprint(jwk)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	return verificationKey{
+		id:     id,
+		method: method,
+		public: public,
+	}, nil
+}
+
+// keyFor picks the key a token names in its kid header, making sure the
+// token uses the algorithm that key is meant for.
+func (ks *KeySet) keyFor(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("signing method does not match key")
+	}
+	return key.public, nil
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is the document served so other services can verify our tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists every key tokens may currently be verified with, the signing
+// key first.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, id := range ks.order {
+		key := ks.keys[id]
+		jwk := publicJWK(key.public)
+		jwk.Kid = key.id
+		jwk.Use = "sig"
+		jwk.Alg = key.method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func publicJWK(public crypto.PublicKey) JWK {
+	enc := base64.RawURLEncoding
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: enc.EncodeToString(key)}
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   enc.EncodeToString(key.N.Bytes()),
+			E:   enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	}
+	return JWK{}
+}
+
+// thumbprint is the RFC 7638 thumbprint of a key, used as its key ID so the
+// same key always gets the same ID without any configuration.
+func thumbprint(jwk JWK) (string, error) {
+	var members any
+	switch jwk.Kty {
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		return "", errors.New("unsupported key type")
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -1,21 +1,228 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 1)
+const (
+	PasswordArgon2id = "argon2id"
+	PasswordBcrypt   = "bcrypt"
+)
+
+// Lowest parameters a configuration may ask for. The argon2id floor is the
+// OWASP recommendation.
+const (
+	minArgon2Memory     = 19 * 1024
+	minArgon2Iterations = 2
+	minBcryptCost       = 10
+)
+
+var ErrPasswordMismatch = errors.New("password does not match hash")
+
+// PasswordHasher hashes new passwords with one algorithm and parameter set.
+// Argon2id hashes are stored as PHC strings, for example
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>, and bcrypt hashes in their
+// usual $2a$ form, so every stored hash says how it was made and hashes made
+// under older settings keep verifying.
+type PasswordHasher struct {
+	Algorithm string
+
+	// Argon2id memory in KiB, passes and lanes.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+
+	BcryptCost int
+}
+
+func DefaultPasswordHasher() *PasswordHasher {
+	return &PasswordHasher{
+		Algorithm:   PasswordArgon2id,
+		Memory:      minArgon2Memory,
+		Iterations:  minArgon2Iterations,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+		BcryptCost:  12,
+	}
+}
+
+// ParsePasswordHasher reads a hasher configuration such as
+// "argon2id,m=65536,t=3,p=2" or "bcrypt,cost=12". Parameters left out keep
+// their defaults, and an empty spec gives the default hasher.
+func ParsePasswordHasher(spec string) (*PasswordHasher, error) {
+	h := DefaultPasswordHasher()
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return h, nil
+	}
+
+	fields := strings.Split(spec, ",")
+	h.Algorithm = strings.TrimSpace(fields[0])
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid password hasher parameter %q", field)
+		}
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid password hasher parameter %q", field)
+		}
+		switch {
+		case h.Algorithm == PasswordArgon2id && key == "m":
+			h.Memory = uint32(n)
+		case h.Algorithm == PasswordArgon2id && key == "t":
+			h.Iterations = uint32(n)
+		case h.Algorithm == PasswordArgon2id && key == "p" && n <= 255:
+			h.Parallelism = uint8(n)
+		case h.Algorithm == PasswordBcrypt && key == "cost":
+			h.BcryptCost = int(n)
+		default:
+			return nil, fmt.Errorf("invalid password hasher parameter %q", field)
+		}
+	}
+
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		if h.Memory < minArgon2Memory || h.Iterations < minArgon2Iterations || h.Parallelism < 1 {
+			return nil, fmt.Errorf("argon2id needs at least m=%d,t=%d,p=1", minArgon2Memory, minArgon2Iterations)
+		}
+	case PasswordBcrypt:
+		if h.BcryptCost < minBcryptCost || h.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", minBcryptCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", h.Algorithm)
+	}
+	return h, nil
+}
+
+// Hash hashes password with the hasher's algorithm and parameters.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		salt := make([]byte, h.SaltLength)
+		_, err := rand.Read(salt)
+		if err != nil {
+			return "", err
+		}
+		params := argon2Params{
+			memory:      h.Memory,
+			iterations:  h.Iterations,
+			parallelism: h.Parallelism,
+			salt:        salt,
+			key:         argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength),
+		}
+		return params.String(), nil
+	case PasswordBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		return string(hash), err
+	}
+	return "", fmt.Errorf("unsupported password hash algorithm %q", h.Algorithm)
+}
 
-	return string(hash), err
+// NeedsRehash reports whether hash was made with a different algorithm or
+// different parameters than h would use now.
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		params, err := parseArgon2Hash(hash)
+		if err != nil {
+			return true
+		}
+		return params.memory != h.Memory ||
+			params.iterations != h.Iterations ||
+			params.parallelism != h.Parallelism ||
+			uint32(len(params.salt)) != h.SaltLength ||
+			uint32(len(params.key)) != h.KeyLength
+	case PasswordBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.BcryptCost
+	}
+	return false
 }
 
+// CheckPasswordHash checks password against a hash in any supported format,
+// returning ErrPasswordMismatch if it is wrong.
 func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		params, err := parseArgon2Hash(hash)
+		if err != nil {
+			return err
+		}
+		key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+		if subtle.ConstantTimeCompare(key, params.key) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	case strings.HasPrefix(hash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+	return errors.New("unsupported password hash format")
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (p argon2Params) String() string {
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.iterations, p.parallelism,
+		enc.EncodeToString(p.salt), enc.EncodeToString(p.key))
+}
+
+func parseArgon2Hash(hash string) (argon2Params, error) {
+	invalid := errors.New("invalid argon2id hash")
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != PasswordArgon2id {
+		return argon2Params{}, invalid
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return argon2Params{}, invalid
+	}
+
+	params := argon2Params{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil || params.iterations == 0 || params.parallelism == 0 {
+		return argon2Params{}, invalid
+	}
+
+	enc := base64.RawStdEncoding
+	params.salt, err = enc.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, invalid
+	}
+	params.key, err = enc.DecodeString(parts[5])
+	if err != nil || len(params.key) == 0 {
+		return argon2Params{}, invalid
+	}
+	return params, nil
 }
 
 func GetAPIKey(headers http.Header) (string, error) {
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+const minRefreshTokenKeyLength = 32
+
+// RefreshTokens issues and looks up refresh tokens. Only an HMAC-SHA256 of
+// each token, keyed with a secret the database never sees, is stored, so
+// reading the refresh_tokens table does not give anyone a usable token.
+type RefreshTokens struct {
+	key []byte
+}
+
+func NewRefreshTokens(key []byte) (*RefreshTokens, error) {
+	if len(key) < minRefreshTokenKeyLength {
+		return nil, fmt.Errorf("refresh token key must be at least %d bytes", minRefreshTokenKeyLength)
+	}
+	return &RefreshTokens{key: key}, nil
+}
+
+// Client describes the device a refresh token was issued to, so users can
+// tell their sessions apart.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Hash returns the value stored in place of token.
+func (rt *RefreshTokens) Hash(token string) string {
+	mac := hmac.New(sha256.New, rt.key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Issue creates a refresh token for userID in the given family and returns
+// the plaintext token for the client.
+func (rt *RefreshTokens) Issue(ctx context.Context, q *database.Queries, userID, familyID uuid.UUID, expiresAt time.Time, client Client) (string, error) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		TokenHash: rt.Hash(token),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		FamilyID:  familyID,
+		UserAgent: client.UserAgent,
+		Ip:        client.IP,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Get looks up the stored row for a plaintext token.
+func (rt *RefreshTokens) Get(ctx context.Context, q *database.Queries, token string) (database.RefreshToken, error) {
+	return q.GetRefreshToken(ctx, rt.Hash(token))
+}
+
+// GetForUpdate looks up and locks the stored row for a plaintext token.
+func (rt *RefreshTokens) GetForUpdate(ctx context.Context, q *database.Queries, token string) (database.RefreshToken, error) {
+	return q.GetRefreshTokenForUpdate(ctx, rt.Hash(token))
+}
+
+// Rotate issues the successor of a refresh token in the same family and
+// retires the old one, recording which token replaced it. The successor
+// records the client that used it, and when.
+func (rt *RefreshTokens) Rotate(ctx context.Context, q *database.Queries, old database.RefreshToken, expiresAt time.Time, client Client) (string, error) {
+	if old.RetiredAt.Valid {
+		return "", errors.New("refresh token has already been rotated")
+	}
+	token, err := rt.Issue(ctx, q, old.UserID, old.FamilyID, expiresAt, client)
+	if err != nil {
+		return "", err
+	}
+	err = q.RetireRefreshToken(ctx, database.RetireRefreshTokenParams{
+		TokenHash:  old.TokenHash,
+		ReplacedBy: sql.NullString{String: rt.Hash(token), Valid: true},
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -15,7 +15,7 @@ const upgradeChirpyRed = `-- name: UpgradeChirpyRed :one
 UPDATE users
 SET is_chirpy_red = true
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, following_count, display_name, bio, avatar_url, is_admin
 `
 
 func (q *Queries) UpgradeChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -28,6 +28,13 @@ func (q *Queries) UpgradeChirpyRed(ctx context.Context, id uuid.UUID) (User, err
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.IsAdmin,
 	)
 	return i, err
 }
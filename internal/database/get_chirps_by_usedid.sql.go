@@ -12,7 +12,7 @@ import (
 )
 
 const getChirpsByAuthor = `-- name: GetChirpsByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, reply_count, tombstoned_at, like_count, rechirp_of, quote_of, rechirp_count, quote_count, deleted_at, visibility FROM chirps
 WHERE user_id = $1
 ORDER BY created_at ASC
 `
@@ -32,6 +32,17 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.BodyTsv,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.DeletedAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -12,7 +12,7 @@ import (
 )
 
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, reply_count, tombstoned_at, like_count, rechirp_of, quote_of, rechirp_count, quote_count, deleted_at, visibility FROM chirps
 WHERE id = $1
 `
 
@@ -25,6 +25,17 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.BodyTsv,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.DeletedAt,
+		&i.Visibility,
 	)
 	return i, err
 }
@@ -10,7 +10,7 @@ import (
 )
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps 
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, reply_count, tombstoned_at, like_count, rechirp_of, quote_of, rechirp_count, quote_count, deleted_at, visibility FROM chirps 
 ORDER BY created_at ASC
 `
 
@@ -29,6 +29,17 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.BodyTsv,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.DeletedAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
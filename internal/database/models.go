@@ -11,21 +11,211 @@ import (
 	"github.com/google/uuid"
 )
 
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	FolderID  uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type BookmarkFolder struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
 type Chirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	BodyTsv      interface{}
+	InReplyTo    uuid.NullUUID
+	ReplyCount   int32
+	TombstonedAt sql.NullTime
+	LikeCount    int32
+	RechirpOf    uuid.NullUUID
+	QuoteOf      uuid.NullUUID
+	RechirpCount int32
+	QuoteCount   int32
+	DeletedAt    sql.NullTime
+	Visibility   string
+}
+
+type ChirpAttachment struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpPin struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
+	ChirpID   uuid.UUID
 	Body      string
-	UserID    uuid.UUID
 }
 
-type RefreshToken struct {
-	Token     string
+type Conversation struct {
+	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+}
+
+type ConversationParticipant struct {
+	ConversationID    uuid.UUID
+	UserID            uuid.UUID
+	JoinedAt          time.Time
+	LastReadAt        sql.NullTime
+	LastReadMessageID uuid.NullUUID
+}
+
+type Draft struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Body       string
+	InReplyTo  uuid.NullUUID
+	QuoteOf    uuid.NullUUID
+	MediaIds   []uuid.UUID
+	Visibility string
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID  uuid.UUID
+	Tag string
+}
+
+type HeldChirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ChirpID      uuid.NullUUID
+	Body         string
+	InReplyTo    uuid.NullUUID
+	QuoteOf      uuid.NullUUID
+	Reason       string
+	MediaIds     []uuid.UUID
+	Visibility   string
+	PollOptions  []string
+	PollClosesAt sql.NullTime
+}
+
+type MediaFile struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UserID        uuid.UUID
+	ContentHash   string
+	ContentType   string
+	SizeBytes     int64
+	Width         int32
+	Height        int32
+	ThumbnailHash sql.NullString
+	AltText       string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type ModerationRule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Pattern   string
+	Action    string
+}
+
+type Poll struct {
+	ChirpID  uuid.UUID
+	ClosesAt time.Time
+}
+
+type PollOption struct {
+	ChirpID   uuid.UUID
+	Position  int32
+	Text      string
+	VoteCount int32
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	Position  int32
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	TokenHash  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	FamilyID   uuid.UUID
+	RetiredAt  sql.NullTime
+	ReplacedBy sql.NullString
+	UserAgent  string
+	Ip         string
+	LastUsedAt time.Time
+}
+
+type ScheduledChirp struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Body       string
+	InReplyTo  uuid.NullUUID
+	QuoteOf    uuid.NullUUID
+	MediaIds   []uuid.UUID
+	PublishAt  time.Time
+	FailedAt   sql.NullTime
+	Failure    string
+	Visibility string
+}
+
+type TrashPurgeFailure struct {
+	ChirpID  uuid.UUID
+	Attempts int32
+	FailedAt time.Time
+	RetryAt  time.Time
+	Failure  string
 }
 
 type User struct {
@@ -35,4 +225,11 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Username       sql.NullString
+	FollowerCount  int32
+	FollowingCount int32
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	IsAdmin        bool
 }
@@ -7,19 +7,22 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 const updateUser = `-- name: UpdateUser :exec
 UPDATE users
-SET updated_at = NOW(), email = $1, hashed_password = $2
+SET updated_at = NOW(),
+    email = COALESCE($1, email),
+    hashed_password = COALESCE($2, hashed_password)
 WHERE id = $3
 `
 
 type UpdateUserParams struct {
-	Email          string
-	HashedPassword string
+	Email          sql.NullString
+	HashedPassword sql.NullString
 	ID             uuid.UUID
 }
 
@@ -27,3 +30,36 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 	_, err := q.db.ExecContext(ctx, updateUser, arg.Email, arg.HashedPassword, arg.ID)
 	return err
 }
+
+const updateUsername = `-- name: UpdateUsername :exec
+UPDATE users
+SET updated_at = NOW(), username = $1
+WHERE id = $2
+`
+
+type UpdateUsernameParams struct {
+	Username sql.NullString
+	ID       uuid.UUID
+}
+
+func (q *Queries) UpdateUsername(ctx context.Context, arg UpdateUsernameParams) error {
+	_, err := q.db.ExecContext(ctx, updateUsername, arg.Username, arg.ID)
+	return err
+}
+
+const upgradePasswordHash = `-- name: UpgradePasswordHash :exec
+UPDATE users
+SET hashed_password = $1
+WHERE id = $2 AND hashed_password = $3
+`
+
+type UpgradePasswordHashParams struct {
+	NewHash string
+	ID      uuid.UUID
+	OldHash string
+}
+
+func (q *Queries) UpgradePasswordHash(ctx context.Context, arg UpgradePasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, upgradePasswordHash, arg.NewHash, arg.ID, arg.OldHash)
+	return err
+}
@@ -0,0 +1,145 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 100
+const minUsernameLength = 3
+const maxUsernameLength = 30
+
+// Hashtag is a #tag found in a piece of text. Start and End are offsets in
+// Unicode code points, covering the tag including its leading '#'.
+type Hashtag struct {
+	Tag   string
+	Start int
+	End   int
+}
+
+// Hashtags returns every hashtag in text in the order they appear. Tags are
+// normalized to lower case. A '#' only starts a tag at the beginning of the
+// text or after a character that could not be part of a word, and a tag made
+// up only of digits is ignored so "#1" stays plain text.
+func Hashtags(text string) []Hashtag {
+	tags := []Hashtag{}
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' && runes[i] != '＃' {
+			continue
+		}
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		tag := runes[i+1 : end]
+		if len(tag) == 0 || len(tag) > maxHashtagLength || !hasLetter(tag) {
+			i = end - 1
+			continue
+		}
+
+		tags = append(tags, Hashtag{
+			Tag:   strings.ToLower(string(tag)),
+			Start: i,
+			End:   end,
+		})
+		i = end - 1
+	}
+
+	return tags
+}
+
+// Mention is an @username found in a piece of text. Username is as written,
+// without the '@'; Start and End are code point offsets covering the '@'.
+type Mention struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// Mentions returns every @mention in text in the order they appear. Like
+// hashtags, an '@' directly after a word character is ignored, which keeps
+// email addresses from being read as mentions.
+func Mentions(text string) []Mention {
+	mentions := []Mention{}
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' && runes[i] != '＠' {
+			continue
+		}
+		if i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '@') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isUsernameRune(runes[end]) {
+			end++
+		}
+		name := string(runes[i+1 : end])
+		if (end < len(runes) && isWordRune(runes[end])) || !ValidUsername(name) {
+			i = end - 1
+			continue
+		}
+
+		mentions = append(mentions, Mention{
+			Username: name,
+			Start:    i,
+			End:      end,
+		})
+		i = end - 1
+	}
+
+	return mentions
+}
+
+// ValidUsername reports whether name can be used as a username: 3 to 30
+// ASCII letters, digits or underscores.
+func ValidUsername(name string) bool {
+	if len(name) < minUsernameLength || len(name) > maxUsernameLength {
+		return false
+	}
+	for _, r := range name {
+		if !isUsernameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// UniqueTags returns the distinct tag names from hashtags, keeping the order
+// of their first appearance.
+func UniqueTags(hashtags []Hashtag) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, h := range hashtags {
+		if seen[h.Tag] {
+			continue
+		}
+		seen[h.Tag] = true
+		tags = append(tags, h.Tag)
+	}
+	return tags
+}
+
+func isUsernameRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+func hasLetter(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Hashtag
+	}{
+		{
+			name: "No hashtags",
+			text: "just a chirp",
+			want: []Hashtag{},
+		},
+		{
+			name: "Tags are lower cased with offsets",
+			text: "#Go is fun #golang_tips!",
+			want: []Hashtag{
+				{Tag: "go", Start: 0, End: 3},
+				{Tag: "golang_tips", Start: 11, End: 23},
+			},
+		},
+		{
+			name: "Offsets count code points",
+			text: "héllo #café",
+			want: []Hashtag{
+				{Tag: "café", Start: 6, End: 11},
+			},
+		},
+		{
+			name: "Hash inside a word is ignored",
+			text: "issue#12 c#sharp",
+			want: []Hashtag{},
+		},
+		{
+			name: "Numeric tags are ignored",
+			text: "we're #1 in #2024goals",
+			want: []Hashtag{
+				{Tag: "2024goals", Start: 12, End: 22},
+			},
+		},
+		{
+			name: "Bare hash",
+			text: "# and ##",
+			want: []Hashtag{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Hashtags(tc.text)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Hashtags() got = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUniqueTags(t *testing.T) {
+	got := UniqueTags(Hashtags("#Go #go #chirpy #GO"))
+	want := []string{"go", "chirpy"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueTags() got = %v, want %v", got, want)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Mention
+	}{
+		{
+			name: "Mentions with offsets",
+			text: "hi @Alice and @bob_99!",
+			want: []Mention{
+				{Username: "Alice", Start: 3, End: 9},
+				{Username: "bob_99", Start: 14, End: 21},
+			},
+		},
+		{
+			name: "Email addresses are ignored",
+			text: "mail me at alice@example.com",
+			want: []Mention{},
+		},
+		{
+			name: "Too short or too long",
+			text: "@al @" + strings.Repeat("a", 31),
+			want: []Mention{},
+		},
+		{
+			name: "Non-ASCII continuation is not a mention",
+			text: "@josé",
+			want: []Mention{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Mentions(tc.text)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Mentions() got = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxDimension bounds the width and height of an upload, and MaxPixels its
+// area. Both are checked from the header before any pixels are decoded, so
+// a small but highly compressed file cannot decode into gigabytes of pixels.
+const MaxDimension = 8192
+const MaxPixels = 40_000_000
+
+// ThumbnailSize is the longest side of a generated thumbnail.
+const ThumbnailSize = 320
+
+var ErrUnsupportedType = errors.New("unsupported media type")
+var ErrTooLarge = errors.New("media dimensions are too large")
+
+var decoders = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Info describes an uploaded file.
+type Info struct {
+	ContentType string
+	Width       int
+	Height      int
+	Hash        string
+}
+
+// Inspect sniffs the type of data from its contents rather than trusting
+// the client, and reads its dimensions without decoding the pixels.
+func Inspect(data []byte) (Info, error) {
+	contentType := http.DetectContentType(data)
+	format, ok := decoders[contentType]
+	if !ok {
+		return Info{}, ErrUnsupportedType
+	}
+
+	cfg, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return Info{}, fmt.Errorf("%w: %s could not be decoded", ErrUnsupportedType, contentType)
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return Info{}, ErrTooLarge
+	}
+
+	return Info{
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Hash:        Hash(data),
+	}, nil
+}
+
+// Hash returns the content hash media is stored and deduplicated under.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ThumbnailType is the content type of thumbnails made from contentType.
+// Photos stay JPEG; everything else becomes PNG so transparency survives.
+func ThumbnailType(contentType string) string {
+	if contentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// Thumbnail scales an inspected image down so its longest side is
+// ThumbnailSize. It returns nil when the image is already that small.
+// Animated GIFs are reduced to their first frame.
+func Thumbnail(data []byte, info Info) ([]byte, error) {
+	if info.Width <= ThumbnailSize && info.Height <= ThumbnailSize {
+		return nil, nil
+	}
+
+	var src image.Image
+	var err error
+	switch info.ContentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, ErrUnsupportedType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := ThumbnailSize, ThumbnailSize
+	if info.Width > info.Height {
+		height = max(1, info.Height*ThumbnailSize/info.Width)
+	} else {
+		width = max(1, info.Width*ThumbnailSize/info.Height)
+	}
+	thumb := scale(src, width, height)
+
+	var buf bytes.Buffer
+	if ThumbnailType(info.ContentType) == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scale shrinks src to width x height, averaging the block of source pixels
+// behind each destination pixel.
+func scale(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	srcW, srcH := rgba.Rect.Dx(), rgba.Rect.Dy()
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcH/height, max((y+1)*srcH/height, y*srcH/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcW/width, max((x+1)*srcW/width, x*srcW/width+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+
+			n := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,193 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader returns just the signature and IHDR chunk of a PNG, enough to
+// read its dimensions without encoding any pixels.
+func pngHeader(width, height uint32) []byte {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, width)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, height)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(ihdr)-4))
+	data = append(data, ihdr...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantType   string
+		wantWidth  int
+		wantHeight int
+		wantErr    error
+	}{
+		{
+			name:       "PNG image",
+			data:       encodePNG(t, 40, 30),
+			wantType:   "image/png",
+			wantWidth:  40,
+			wantHeight: 30,
+		},
+		{
+			name:    "Plain text",
+			data:    []byte("definitely not an image"),
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Truncated image",
+			data:    encodePNG(t, 40, 30)[:20],
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Too large",
+			data:    encodePNG(t, MaxDimension+1, 1),
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:       "Large but allowed",
+			data:       pngHeader(8000, 5000),
+			wantType:   "image/png",
+			wantWidth:  8000,
+			wantHeight: 5000,
+		},
+		{
+			name:    "Too many pixels",
+			data:    pngHeader(MaxDimension, MaxDimension),
+			wantErr: ErrTooLarge,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Inspect(tc.data)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Inspect() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if got.ContentType != tc.wantType || got.Width != tc.wantWidth || got.Height != tc.wantHeight {
+				t.Errorf("Inspect() got = %+v", got)
+			}
+			if got.Hash != Hash(tc.data) {
+				t.Errorf("Inspect() hash = %s, want %s", got.Hash, Hash(tc.data))
+			}
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:  "Already small",
+			width: 100, height: 80,
+		},
+		{
+			name:  "Landscape",
+			width: 640, height: 480,
+			wantWidth: 320, wantHeight: 240,
+		},
+		{
+			name:  "Portrait",
+			width: 400, height: 1000,
+			wantWidth: 128, wantHeight: 320,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := encodePNG(t, tc.width, tc.height)
+			info, err := Inspect(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			thumb, err := Thumbnail(data, info)
+			if err != nil {
+				t.Fatalf("Thumbnail() error = %v", err)
+			}
+			if tc.wantWidth == 0 {
+				if thumb != nil {
+					t.Errorf("Thumbnail() made a thumbnail for a small image")
+				}
+				return
+			}
+			got, err := Inspect(thumb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Width != tc.wantWidth || got.Height != tc.wantHeight {
+				t.Errorf("Thumbnail() got %dx%d, want %dx%d", got.Width, got.Height, tc.wantWidth, tc.wantHeight)
+			}
+		})
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("blob contents")
+	key := Hash(data)
+	for i := 0; i < 2; i++ {
+		if err := storage.Put(ctx, key, bytes.NewReader(data)); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	f, err := storage.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	got, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Open() got = %q, %v", got, err)
+	}
+
+	if _, err := storage.Open(ctx, Hash([]byte("missing"))); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() missing error = %v, want %v", err, ErrNotFound)
+	}
+	if err := storage.Put(ctx, "../../etc/passwd", strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put() traversal error = %v, want %v", err, ErrInvalidKey)
+	}
+}
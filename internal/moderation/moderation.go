@@ -0,0 +1,197 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Mask replaces text matched by a censor rule.
+const Mask = "****"
+
+// Action is what happens to a text that matches a rule.
+type Action string
+
+const (
+	// ActionNone means no rule matched.
+	ActionNone Action = ""
+	// ActionCensor masks the matched words and lets the text through.
+	ActionCensor Action = "censor"
+	// ActionHold keeps the text from being published until a moderator
+	// approves it.
+	ActionHold Action = "hold"
+	// ActionReject refuses the text outright.
+	ActionReject Action = "reject"
+)
+
+var severity = map[Action]int{
+	ActionNone:   0,
+	ActionCensor: 1,
+	ActionHold:   2,
+	ActionReject: 3,
+}
+
+// MoreSevere reports whether a is a stronger response than b.
+func (a Action) MoreSevere(b Action) bool {
+	return severity[a] > severity[b]
+}
+
+// ParseAction validates the name of an action.
+func ParseAction(s string) (Action, error) {
+	a := Action(strings.ToLower(strings.TrimSpace(s)))
+	if severity[a] == 0 {
+		return ActionNone, fmt.Errorf("unknown moderation action %q", s)
+	}
+	return a, nil
+}
+
+// Rule is a word or phrase and the action to take when it appears.
+type Rule struct {
+	Pattern string
+	Action  Action
+}
+
+// Match is a place where a rule matched. Start and End are byte offsets
+// into the text that was checked.
+type Match struct {
+	Rule  Rule
+	Start int
+	End   int
+}
+
+// Filter finds the parts of a text that break a rule.
+type Filter interface {
+	Match(text string) []Match
+}
+
+// Result is the outcome of running a text through a set of filters.
+type Result struct {
+	// Text is the input with every censored match masked.
+	Text string
+	// Action is the most severe action of any match.
+	Action Action
+	// Matches lists every match in the order it appears in the text.
+	Matches []Match
+}
+
+// Check runs text through every filter and combines what they found.
+func Check(text string, filters ...Filter) Result {
+	result := Result{Text: text, Matches: []Match{}}
+	for _, f := range filters {
+		result.Matches = append(result.Matches, f.Match(text)...)
+	}
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].Start < result.Matches[j].Start
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, m := range result.Matches {
+		if m.Rule.Action.MoreSevere(result.Action) {
+			result.Action = m.Rule.Action
+		}
+		if m.Rule.Action != ActionCensor {
+			continue
+		}
+		if m.Start < last {
+			last = max(last, m.End)
+			continue
+		}
+		b.WriteString(text[last:m.Start])
+		b.WriteString(Mask)
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	result.Text = b.String()
+	return result
+}
+
+// WordList matches rules against whole words, so a rule never fires on
+// part of a longer word. Both the rules and the text are normalized first,
+// which catches accented, fullwidth and leet-speak spellings.
+type WordList struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	rule  Rule
+	words []string
+}
+
+// NewWordList compiles rules into a filter. Rules that normalize to nothing
+// are dropped.
+func NewWordList(rules []Rule) *WordList {
+	wl := &WordList{}
+	for _, rule := range rules {
+		words := strings.Fields(Normalize(rule.Pattern))
+		if len(words) == 0 {
+			continue
+		}
+		wl.rules = append(wl.rules, compiledRule{rule: rule, words: words})
+	}
+	return wl
+}
+
+// Match implements Filter.
+func (wl *WordList) Match(text string) []Match {
+	tokens := tokenize(text)
+	matches := []Match{}
+	for i := range tokens {
+		for _, cr := range wl.rules {
+			if m, ok := cr.matchAt(tokens, i); ok {
+				matches = append(matches, m)
+			}
+		}
+	}
+	return matches
+}
+
+func (cr compiledRule) matchAt(tokens []token, i int) (Match, bool) {
+	if i+len(cr.words) > len(tokens) {
+		return Match{}, false
+	}
+	m := Match{Rule: cr.rule}
+	for j, word := range cr.words {
+		f, ok := tokens[i+j].read(word)
+		if !ok {
+			return Match{}, false
+		}
+		if j == 0 {
+			m.Start = f.start
+		}
+		m.End = f.end
+	}
+	return m, true
+}
+
+// ParseRules reads rules from a word list file. Each line holds an action
+// followed by the word or phrase it applies to, for example
+// "censor kerfuffle". Blank lines and lines starting with '#' are skipped.
+func ParseRules(r io.Reader) ([]Rule, error) {
+	rules := []Rule{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, pattern, ok := strings.Cut(text, " ")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("line %d: expected an action and a pattern", line)
+		}
+		action, err := ParseAction(name)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules = append(rules, Rule{Pattern: pattern, Action: action})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
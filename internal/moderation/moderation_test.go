@@ -0,0 +1,150 @@
+package moderation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "Case and punctuation",
+			text: "Hello, World!",
+			want: "hello world",
+		},
+		{
+			name: "Leet speak",
+			text: "f0rn@x $harb3rt",
+			want: "fornax sharbert",
+		},
+		{
+			name: "Accents, fullwidth and look-alikes",
+			text: "kérfüffle ｆｏｒｎａｘ fоrnах",
+			want: "kerfuffle fornax fornax",
+		},
+		{
+			name: "Invisible characters",
+			text: "for​nax",
+			want: "fornax",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Normalize(tc.text)
+			if got != tc.want {
+				t.Errorf("Normalize() got = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	filter := NewWordList([]Rule{
+		{Pattern: "kerfuffle", Action: ActionCensor},
+		{Pattern: "fornax", Action: ActionCensor},
+		{Pattern: "buy followers", Action: ActionHold},
+		{Pattern: "sharbert", Action: ActionReject},
+	})
+
+	tests := []struct {
+		name       string
+		text       string
+		wantText   string
+		wantAction Action
+	}{
+		{
+			name:       "Clean text",
+			text:       "I had something interesting for breakfast",
+			wantText:   "I had something interesting for breakfast",
+			wantAction: ActionNone,
+		},
+		{
+			name:       "Punctuation is kept",
+			text:       "What a Fornax!",
+			wantText:   "What a ****!",
+			wantAction: ActionCensor,
+		},
+		{
+			name:       "Substrings elsewhere are untouched",
+			text:       "fornax fornaxes",
+			wantText:   "**** fornaxes",
+			wantAction: ActionCensor,
+		},
+		{
+			name:       "Disguised spelling",
+			text:       "such a k3rfüffle",
+			wantText:   "such a ****",
+			wantAction: ActionCensor,
+		},
+		{
+			name:       "Phrase held",
+			text:       "Buy   followers now",
+			wantText:   "Buy   followers now",
+			wantAction: ActionHold,
+		},
+		{
+			name:       "Most severe action wins",
+			text:       "fornax $harbert",
+			wantText:   "**** $harbert",
+			wantAction: ActionReject,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Check(tc.text, filter)
+			if got.Text != tc.wantText {
+				t.Errorf("Check() text = %q, want %q", got.Text, tc.wantText)
+			}
+			if got.Action != tc.wantAction {
+				t.Errorf("Check() action = %q, want %q", got.Action, tc.wantAction)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Rule
+		wantErr bool
+	}{
+		{
+			name:  "Rules with comments",
+			input: "# profanity\ncensor kerfuffle\n\nhold  buy followers \n",
+			want: []Rule{
+				{Pattern: "kerfuffle", Action: ActionCensor},
+				{Pattern: "buy followers", Action: ActionHold},
+			},
+		},
+		{
+			name:    "Unknown action",
+			input:   "ban fornax",
+			wantErr: true,
+		},
+		{
+			name:    "Missing pattern",
+			input:   "reject",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseRules(strings.NewReader(tc.input))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseRules() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseRules() got = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
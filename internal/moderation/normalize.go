@@ -0,0 +1,168 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// leet maps digits and symbols commonly swapped in for letters.
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+// fold maps accented Latin letters and look-alike Cyrillic and Greek letters
+// to the plain ASCII letter they are usually read as.
+var fold = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ā': 'a',
+	'ç': 'c', 'ć': 'c', 'č': 'c',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e', 'ę': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ī': 'i', 'ı': 'i',
+	'ñ': 'n', 'ń': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o', 'ō': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ū': 'u',
+	'ý': 'y', 'ÿ': 'y',
+	'ś': 's', 'š': 's',
+	'ź': 'z', 'ż': 'z', 'ž': 'z',
+	'а': 'a', 'в': 'b', 'е': 'e', 'і': 'i', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x',
+	'α': 'a', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x',
+}
+
+// token is a run of word characters in the original text. Leet symbols at
+// the edges of a run are as often punctuation ("fornax!", "@fornax") as
+// disguise ("$harbert"), so a token offers a few readings of itself.
+type token struct {
+	forms []form
+}
+
+// form is one reading of a token, covering text[start:end].
+type form struct {
+	start, end int
+	norm       string
+}
+
+// Normalize folds text to the form rules are matched against: lower case,
+// accents and look-alike letters mapped to ASCII, leet-speak undone, and
+// invisible characters removed. Trailing punctuation is dropped and words
+// are separated by single spaces.
+func Normalize(text string) string {
+	words := []string{}
+	for _, t := range tokenize(text) {
+		if norm := t.forms[1].norm; norm != "" {
+			words = append(words, norm)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range text {
+		if isTokenRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, newToken(text, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+	return tokens
+}
+
+// newToken builds the readings of text[start:end]: as written, without
+// trailing symbols, and without symbols at either end.
+func newToken(text string, start, end int) token {
+	trimEnd := end
+	for trimEnd > start {
+		r, size := utf8.DecodeLastRuneInString(text[start:trimEnd])
+		if !isSymbol(r) {
+			break
+		}
+		trimEnd -= size
+	}
+	trimStart := start
+	for trimStart < trimEnd {
+		r, size := utf8.DecodeRuneInString(text[trimStart:trimEnd])
+		if !isSymbol(r) {
+			break
+		}
+		trimStart += size
+	}
+
+	return token{
+		forms: []form{
+			{start: start, end: end, norm: normalizeWord(text[start:end])},
+			{start: start, end: trimEnd, norm: normalizeWord(text[start:trimEnd])},
+			{start: trimStart, end: trimEnd, norm: normalizeWord(text[trimStart:trimEnd])},
+		},
+	}
+}
+
+// read returns the first reading of the token that spells word.
+func (t token) read(word string) (form, bool) {
+	for _, f := range t.forms {
+		if f.norm == word {
+			return f, true
+		}
+	}
+	return form{}, false
+}
+
+func normalizeWord(word string) string {
+	var b strings.Builder
+	for _, r := range word {
+		r = widen(r)
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if f, ok := fold[r]; ok {
+			r = f
+		}
+		if l, ok := leet[r]; ok {
+			r = l
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// widen maps fullwidth ASCII forms to their ASCII equivalents.
+func widen(r rune) rune {
+	if r >= '！' && r <= '～' {
+		return r - '！' + '!'
+	}
+	return r
+}
+
+func isTokenRune(r rune) bool {
+	r = widen(r)
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) ||
+		unicode.Is(unicode.Cf, r) || r == '_' || isSymbol(r)
+}
+
+func isSymbol(r rune) bool {
+	r = widen(r)
+	_, ok := leet[r]
+	return ok && !unicode.IsDigit(r)
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ToTSQuery converts a user supplied search string into a Postgres tsquery
+// expression. Words are ANDed together, "quoted phrases" must match in order
+// and a trailing * turns a word into a prefix match. Anything that is not a
+// letter or digit is dropped, so the result is always safe to hand to
+// to_tsquery.
+func ToTSQuery(q string) (string, error) {
+	terms := []string{}
+
+	parts := strings.Split(q, `"`)
+	for i, part := range parts {
+		inPhrase := i%2 == 1 && i < len(parts)-1
+		if inPhrase {
+			lexemes := []string{}
+			for _, word := range strings.Fields(part) {
+				lexemes = append(lexemes, splitLexemes(word)...)
+			}
+			if len(lexemes) > 0 {
+				terms = append(terms, quoteLexemes(lexemes, false))
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			prefix := strings.HasSuffix(word, "*")
+			lexemes := splitLexemes(word)
+			if len(lexemes) == 0 {
+				continue
+			}
+			terms = append(terms, quoteLexemes(lexemes, prefix))
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("search query is empty")
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+func splitLexemes(word string) []string {
+	return strings.FieldsFunc(strings.ToLower(word), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// quoteLexemes joins lexemes into a phrase. :* cannot follow a parenthesised
+// group, so a prefix match applies to the last lexeme only.
+func quoteLexemes(lexemes []string, prefix bool) string {
+	quoted := make([]string, len(lexemes))
+	for i, l := range lexemes {
+		quoted[i] = "'" + l + "'"
+	}
+	if prefix {
+		quoted[len(quoted)-1] += ":*"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return "(" + strings.Join(quoted, " <-> ") + ")"
+}
@@ -0,0 +1,66 @@
+package search
+
+import "testing"
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Single word",
+			query: "chirpy",
+			want:  "'chirpy'",
+		},
+		{
+			name:  "Words are ANDed",
+			query: "Hello World",
+			want:  "'hello' & 'world'",
+		},
+		{
+			name:  "Prefix match",
+			query: "chirp*",
+			want:  "'chirp':*",
+		},
+		{
+			name:  "Prefix match on a split word",
+			query: "e-mail*",
+			want:  "('e' <-> 'mail':*)",
+		},
+		{
+			name:  "Phrase match",
+			query: `"big brown fox" jumps`,
+			want:  "('big' <-> 'brown' <-> 'fox') & 'jumps'",
+		},
+		{
+			name:  "Unterminated quote is treated as words",
+			query: `"big brown`,
+			want:  "'big' & 'brown'",
+		},
+		{
+			name:  "Operators are stripped",
+			query: "it's & !|(drop)",
+			want:  "('it' <-> 's') & 'drop'",
+		},
+		{
+			name:    "Empty query",
+			query:   `  "" !& `,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ToTSQuery(tc.query)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ToTSQuery() error = %v, wantErr %v", err, tc.wantErr)
+				return
+			}
+			if got != tc.want {
+				t.Errorf("ToTSQuery() got = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
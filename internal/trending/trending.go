@@ -0,0 +1,50 @@
+package trending
+
+import (
+	"math"
+	"sort"
+)
+
+// BaselineWindows is how many windows before the current one are used to
+// work out a tag's normal rate of use.
+const BaselineWindows = 4
+
+// Tag is a hashtag with its usage in the current window and in the baseline
+// period immediately before it.
+type Tag struct {
+	Tag           string
+	RecentCount   int64
+	BaselineCount int64
+	Score         float64
+}
+
+// Score measures how far a tag's use in the current window is above what its
+// baseline predicts, scaled by the square root of that expectation so a jump
+// from 0 to 10 outranks a steady 500 becoming 510.
+func Score(recent, baseline int64) float64 {
+	expected := float64(baseline) / BaselineWindows
+	return (float64(recent) - expected) / math.Sqrt(expected+1)
+}
+
+// Rank scores every tag and returns the top limit of them, highest score
+// first. Ties go to the tag with more recent uses, then alphabetically.
+func Rank(tags []Tag, limit int) []Tag {
+	for i := range tags {
+		tags[i].Score = Score(tags[i].RecentCount, tags[i].BaselineCount)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Score != tags[j].Score {
+			return tags[i].Score > tags[j].Score
+		}
+		if tags[i].RecentCount != tags[j].RecentCount {
+			return tags[i].RecentCount > tags[j].RecentCount
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+	return tags
+}
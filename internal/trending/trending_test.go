@@ -0,0 +1,61 @@
+package trending
+
+import "testing"
+
+func TestRank(t *testing.T) {
+	tags := []Tag{
+		{Tag: "steady", RecentCount: 510, BaselineCount: 2000},
+		{Tag: "rising", RecentCount: 10, BaselineCount: 0},
+		{Tag: "fading", RecentCount: 5, BaselineCount: 400},
+		{Tag: "also_rising", RecentCount: 10, BaselineCount: 0},
+	}
+
+	got := Rank(tags, 3)
+
+	want := []string{"also_rising", "rising", "steady"}
+	if len(got) != len(want) {
+		t.Fatalf("Rank() returned %d tags, want %d", len(got), len(want))
+	}
+	for i, tag := range got {
+		if tag.Tag != want[i] {
+			t.Errorf("Rank()[%d] = %v, want %v", i, tag.Tag, want[i])
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		recent   int64
+		baseline int64
+		wantSign int
+	}{
+		{
+			name:     "New tag",
+			recent:   3,
+			baseline: 0,
+			wantSign: 1,
+		},
+		{
+			name:     "Steady tag",
+			recent:   25,
+			baseline: 100,
+			wantSign: 0,
+		},
+		{
+			name:     "Cooling tag",
+			recent:   1,
+			baseline: 100,
+			wantSign: -1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Score(tc.recent, tc.baseline)
+			if (got > 0 && tc.wantSign != 1) || (got < 0 && tc.wantSign != -1) || (got == 0 && tc.wantSign != 0) {
+				t.Errorf("Score() = %v, want sign %v", got, tc.wantSign)
+			}
+		})
+	}
+}
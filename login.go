@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -48,26 +50,24 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	cfg.upgradePasswordHash(r.Context(), user, login.Password)
 
-	token, err := auth.MakeJWT(user.ID, cfg.secret, time.Duration(expiresIn)*time.Second)
+	// The refresh token family doubles as the session ID, so access tokens
+	// carry it and know which session they belong to.
+	familyID := uuid.New()
+	token, err := auth.MakeJWT(user.ID, familyID, cfg.jwtKeys, time.Duration(expiresIn)*time.Second)
 	if err != nil {
 		log.Printf("Error making JWT: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	refreshToken, err := auth.MakeRefreshToken()
+	refreshToken, err := cfg.refreshTokens.Issue(r.Context(), cfg.db, user.ID, familyID, time.Now().UTC().Add(refreshTokenLifetime), clientFor(r))
 	if err != nil {
 		log.Printf("Error making refresh token: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	hoursIn60Days := 1440
-	_, _ = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(time.Duration(hoursIn60Days) * time.Hour),
-	})
 
 	type userReturnVals struct {
 		Id           uuid.UUID `json:"id"`
@@ -91,6 +91,27 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// upgradePasswordHash rehashes a password that has just been verified if its
+// stored hash was made with an older algorithm or weaker parameters. The
+// update only applies if the hash has not changed in the meantime, and a
+// failure just leaves the old hash in place for next time.
+func (cfg *apiConfig) upgradePasswordHash(ctx context.Context, user database.User, password string) {
+	if !cfg.passwords.NeedsRehash(user.HashedPassword) {
+		return
+	}
+	hash, err := cfg.passwords.Hash(password)
+	if err == nil {
+		err = cfg.db.UpgradePasswordHash(ctx, database.UpgradePasswordHashParams{
+			NewHash: hash,
+			ID:      user.ID,
+			OldHash: user.HashedPassword,
+		})
+	}
+	if err != nil {
+		log.Printf("Error upgrading password hash: %s", err)
+	}
+}
+
 func (cfg *apiConfig) middlewareAuth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		token, err := auth.GetBearerToken(r.Header)
@@ -99,17 +120,38 @@ func (cfg *apiConfig) middlewareAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		userUUID, err := auth.ValidateJWT(token, cfg.secret)
+		accessToken, err := auth.ParseAccessToken(token, cfg.jwtKeys)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		ctx := context.WithValue(r.Context(), "userID", userUUID)
+		ctx := context.WithValue(r.Context(), "userID", accessToken.UserID)
+		ctx = context.WithValue(ctx, "sessionID", accessToken.SessionID)
 		r = r.WithContext(ctx)
 		next(w, r)
 	}
 }
 
+// middlewareAdmin only lets through authenticated users flagged as admins.
+func (cfg *apiConfig) middlewareAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request) {
+		user, err := cfg.db.GetUserByID(r.Context(), r.Context().Value("userID").(uuid.UUID))
+		if err != nil || !user.IsAdmin {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// refreshTokenLifetime is how long a refresh token can be used. Every
+// refresh swaps it for a new one with a fresh lifetime.
+const refreshTokenLifetime = 60 * 24 * time.Hour
+
+// handlerRefresh trades a refresh token for a new access token and a new
+// refresh token, retiring the one presented. A retired token should never
+// come back; if it does, someone else holds a copy, so its whole family is
+// revoked and the legitimate client has to log in again.
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -117,28 +159,62 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	refreshToken, err := cfg.db.GetRefreshToken(r.Context(), token)
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	refreshToken, err := cfg.refreshTokens.GetForUpdate(r.Context(), qtx, token)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	if refreshToken.RevokedAt.Valid {
+	if refreshToken.RevokedAt.Valid || time.Now().UTC().After(refreshToken.ExpiresAt) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	if refreshToken.RetiredAt.Valid {
+		err = qtx.RevokeRefreshTokenFamily(r.Context(), refreshToken.FamilyID)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("Error revoking reused refresh token family: %s", err)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	newRefreshToken, err := cfg.refreshTokens.Rotate(r.Context(), qtx, refreshToken, time.Now().UTC().Add(refreshTokenLifetime), clientFor(r))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to rotate refresh token")
+		return
+	}
 
-	jwt, err := auth.MakeJWT(refreshToken.UserID, cfg.secret, time.Duration(3600)*time.Second)
+	jwt, err := auth.MakeJWT(refreshToken.UserID, refreshToken.FamilyID, cfg.jwtKeys, time.Duration(3600)*time.Second)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing refresh token rotation: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	type returnVal struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	respondWithJSON(w, http.StatusOK, returnVal{
-		Token: jwt,
+		Token:        jwt,
+		RefreshToken: newRefreshToken,
 	})
 
 }
@@ -150,7 +226,16 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = cfg.db.RevokeRefreshToken(r.Context(), token)
+	// Revoking one token logs out the whole family, including any token it
+	// has since been rotated into.
+	refreshToken, err := cfg.refreshTokens.Get(r.Context(), cfg.db, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNoContent, nil)
+		return
+	}
+	if err == nil {
+		err = cfg.db.RevokeRefreshTokenFamily(r.Context(), refreshToken.FamilyID)
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
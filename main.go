@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/Chase-Outman/GitLab/internal/auth"
 	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/media"
+	"github.com/Chase-Outman/GitLab/internal/moderation"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -15,9 +22,28 @@ import (
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	sqlDB          *sql.DB
 	platform       string
-	secret         string
 	apiKey         string
+
+	// jwtKeys signs access tokens and verifies them against the current
+	// and previous signing keys.
+	jwtKeys *auth.KeySet
+	// refreshTokens keys the hashes refresh tokens are stored as.
+	refreshTokens *auth.RefreshTokens
+	// passwords hashes new passwords. Logging in with a password hashed
+	// under other settings rehashes it with these.
+	passwords *auth.PasswordHasher
+
+	// moderationRules are read from MODERATION_RULES_FILE at startup and
+	// apply alongside the rules stored in the database.
+	moderationRules []moderation.Rule
+
+	storage media.Storage
+
+	// trashRetention is how long a deleted chirp can be restored before it
+	// is purged.
+	trashRetention time.Duration
 }
 
 func main() {
@@ -38,9 +64,31 @@ func main() {
 	dbQueries := database.New(db)
 
 	platfor := os.Getenv("PLATFORM")
-	jwtSecret := os.Getenv("SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT secret is not set in environment variables")
+	signingKeyPath := os.Getenv("JWT_SIGNING_KEY")
+	if signingKeyPath == "" {
+		log.Fatal("JWT_SIGNING_KEY must be set to a PEM private key file")
+	}
+	// Keys that signed tokens before the last rotation, still accepted
+	// until those tokens expire.
+	verifyKeyPaths := []string{}
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFICATION_KEYS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			verifyKeyPaths = append(verifyKeyPaths, path)
+		}
+	}
+	jwtKeys, err := auth.LoadKeySet(signingKeyPath, verifyKeyPaths...)
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %s", err)
+	}
+
+	refreshTokens, err := auth.NewRefreshTokens([]byte(os.Getenv("REFRESH_TOKEN_KEY")))
+	if err != nil {
+		log.Fatalf("REFRESH_TOKEN_KEY: %s", err)
+	}
+
+	passwords, err := auth.ParsePasswordHasher(os.Getenv("PASSWORD_HASHER"))
+	if err != nil {
+		log.Fatalf("PASSWORD_HASHER: %s", err)
 	}
 
 	polkaKey := os.Getenv("POLKA_KEY")
@@ -48,39 +96,150 @@ func main() {
 		log.Fatal("Polka key is not set in environment variables")
 	}
 
+	moderationRules := []moderation.Rule{}
+	if path := os.Getenv("MODERATION_RULES_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Error opening moderation rules: %s", err)
+		}
+		moderationRules, err = moderation.ParseRules(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Error reading moderation rules from %s: %s", path, err)
+		}
+	}
+
+	// Media is only served through serveMedia, which checks visibility and
+	// sets headers, so it must not live where the /app/ file server can
+	// reach it.
+	mediaRoot := os.Getenv("MEDIA_ROOT")
+	if mediaRoot == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			log.Fatal("MEDIA_ROOT must be set when there is no home directory")
+		}
+		mediaRoot = filepath.Join(home, ".chirpy", "media")
+	}
+	if under, err := pathUnder(mediaRoot, filepathRoot); err != nil || under {
+		log.Fatalf("MEDIA_ROOT %q must be outside the served directory %q", mediaRoot, filepathRoot)
+	}
+	storage, err := media.NewLocalStorage(mediaRoot)
+	if err != nil {
+		log.Fatalf("Error opening media storage: %s", err)
+	}
+
+	trashRetention := defaultTrashRetention
+	if v := os.Getenv("CHIRP_TRASH_RETENTION"); v != "" {
+		trashRetention, err = time.ParseDuration(v)
+		if err != nil || trashRetention <= 0 {
+			log.Fatalf("CHIRP_TRASH_RETENTION must be a positive duration, got %q", v)
+		}
+	}
+
 	apiCfg := &apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
+		sqlDB:          db,
 		platform:       platfor,
-		secret:         jwtSecret,
 		apiKey:         polkaKey,
+
+		jwtKeys:       jwtKeys,
+		refreshTokens: refreshTokens,
+		passwords:     passwords,
+
+		moderationRules: moderationRules,
+
+		storage: storage,
+
+		trashRetention: trashRetention,
 	}
 
 	serverMux := http.NewServeMux()
 
 	serverMux.Handle("/app/", apiCfg.middlewareMerticInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))))
 	serverMux.HandleFunc("GET /api/healthz", handler)
+	serverMux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
 	serverMux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	serverMux.HandleFunc("GET /admin/moderation/rules", apiCfg.middlewareAdmin(apiCfg.handlerGetModerationRules))
+	serverMux.HandleFunc("GET /admin/moderation/held", apiCfg.middlewareAdmin(apiCfg.handlerGetHeldChirps))
 	serverMux.HandleFunc("GET /api/chirps/", apiCfg.handlersGetChirps)
 	serverMux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpByID)
+	serverMux.HandleFunc("GET /api/chirps/search", apiCfg.handlerSearchChirps)
+	serverMux.HandleFunc("GET /api/chirps/scheduled", apiCfg.middlewareAuth(apiCfg.handlerGetScheduledChirps))
+	serverMux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.handlerGetChirpRevisions)
+	serverMux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.handlerGetChirpThread)
+	serverMux.HandleFunc("GET /api/users/{idOrUsername}", apiCfg.handlerGetUserProfile)
+	serverMux.HandleFunc("GET /api/users/{id}/likes", apiCfg.handlerGetUserLikes)
+	serverMux.HandleFunc("GET /api/users/{id}/followers", apiCfg.handlerGetFollowers)
+	serverMux.HandleFunc("GET /api/users/{id}/following", apiCfg.handlerGetFollowing)
+	serverMux.HandleFunc("GET /api/media/{mediaID}", apiCfg.handlerGetMedia)
+	serverMux.HandleFunc("GET /api/media/{mediaID}/thumbnail", apiCfg.handlerGetMediaThumbnail)
+	serverMux.HandleFunc("GET /api/conversations", apiCfg.middlewareAuth(apiCfg.handlerGetConversations))
+	serverMux.HandleFunc("GET /api/conversations/{conversationID}", apiCfg.middlewareAuth(apiCfg.handlerGetConversation))
+	serverMux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiCfg.middlewareAuth(apiCfg.handlerGetMessages))
+	serverMux.HandleFunc("GET /api/bookmarks/folders", apiCfg.middlewareAuth(apiCfg.handlerGetBookmarkFolders))
+	serverMux.HandleFunc("GET /api/drafts", apiCfg.middlewareAuth(apiCfg.handlerGetDrafts))
+	serverMux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.middlewareAuth(apiCfg.handlerGetDraft))
+	serverMux.HandleFunc("GET /api/hashtags/trending", apiCfg.handlerGetTrendingHashtags)
+	serverMux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.handlerGetHashtagChirps)
 
 	serverMux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	serverMux.HandleFunc("POST /admin/moderation/rules", apiCfg.middlewareAdmin(apiCfg.handlerCreateModerationRule))
+	serverMux.HandleFunc("POST /admin/moderation/held/{heldID}/approve", apiCfg.middlewareAdmin(apiCfg.handlerApproveHeldChirp))
 	serverMux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
 	serverMux.HandleFunc("POST /api/chirps", apiCfg.middlewareAuth(apiCfg.handlerChirps))
+	serverMux.HandleFunc("POST /api/conversations", apiCfg.middlewareAuth(apiCfg.handlerCreateConversation))
+	serverMux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiCfg.middlewareAuth(apiCfg.handlerSendMessage))
+	serverMux.HandleFunc("POST /api/conversations/{conversationID}/read", apiCfg.middlewareAuth(apiCfg.handlerMarkConversationRead))
+	serverMux.HandleFunc("POST /api/bookmarks/folders", apiCfg.middlewareAuth(apiCfg.handlerCreateBookmarkFolder))
+	serverMux.HandleFunc("POST /api/drafts", apiCfg.middlewareAuth(apiCfg.handlerCreateDraft))
+	serverMux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.middlewareAuth(apiCfg.handlerPublishDraft))
+	serverMux.HandleFunc("POST /api/media", apiCfg.middlewareAuth(apiCfg.handlerUploadMedia))
 	serverMux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 	serverMux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
 	serverMux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
 	serverMux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerWebhooks)
+	serverMux.HandleFunc("POST /api/chirps/{chirpID}/likes", apiCfg.middlewareAuth(apiCfg.handlerLikeChirp))
+	serverMux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.middlewareAuth(apiCfg.handlerRestoreChirp))
+	serverMux.HandleFunc("POST /api/chirps/{chirpID}/votes", apiCfg.middlewareAuth(apiCfg.handlerVotePoll))
+
+	serverMux.HandleFunc("POST /api/users/{id}/follow", apiCfg.middlewareAuth(apiCfg.handlerFollowUser))
+	serverMux.HandleFunc("POST /api/users/me/pins/{chirpID}", apiCfg.middlewareAuth(apiCfg.handlerPinChirp))
+
+	serverMux.HandleFunc("GET /api/users/me/mentions", apiCfg.middlewareAuth(apiCfg.handlerGetMyMentions))
+	serverMux.HandleFunc("GET /api/users/me/trash", apiCfg.middlewareAuth(apiCfg.handlerGetTrash))
+	serverMux.HandleFunc("GET /api/users/me/bookmarks", apiCfg.middlewareAuth(apiCfg.handlerGetMyBookmarks))
+	serverMux.HandleFunc("GET /api/users/me/sessions", apiCfg.middlewareAuth(apiCfg.handlerGetSessions))
+	serverMux.HandleFunc("GET /api/timeline", apiCfg.middlewareAuth(apiCfg.handlerGetTimeline))
 
 	serverMux.HandleFunc("PUT /api/users", apiCfg.middlewareAuth(apiCfg.handlerUpdateUsers))
+	serverMux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.middlewareAuth(apiCfg.handlerUpdateDraft))
+	serverMux.HandleFunc("PUT /api/bookmarks/{chirpID}", apiCfg.middlewareAuth(apiCfg.handlerBookmarkChirp))
 
+	serverMux.HandleFunc("PATCH /api/chirps/{chirpID}", apiCfg.middlewareAuth(apiCfg.handlerUpdateChirp))
+	serverMux.HandleFunc("PATCH /api/media/{mediaID}", apiCfg.middlewareAuth(apiCfg.handlerUpdateMedia))
+
+	serverMux.HandleFunc("DELETE /admin/moderation/rules/{ruleID}", apiCfg.middlewareAdmin(apiCfg.handlerDeleteModerationRule))
+	serverMux.HandleFunc("DELETE /admin/moderation/held/{heldID}", apiCfg.middlewareAdmin(apiCfg.handlerDiscardHeldChirp))
 	serverMux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
+	serverMux.HandleFunc("DELETE /api/chirps/scheduled", apiCfg.middlewareAuth(apiCfg.handlerDeleteScheduledChirp))
+	serverMux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", apiCfg.middlewareAuth(apiCfg.handlerUnlikeChirp))
+	serverMux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteDraft))
+	serverMux.HandleFunc("DELETE /api/bookmarks/{chirpID}", apiCfg.middlewareAuth(apiCfg.handlerUnbookmarkChirp))
+	serverMux.HandleFunc("DELETE /api/bookmarks/folders/{folderID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteBookmarkFolder))
+	serverMux.HandleFunc("DELETE /api/users/{id}/follow", apiCfg.middlewareAuth(apiCfg.handlerUnfollowUser))
+	serverMux.HandleFunc("DELETE /api/users/me/pins/{chirpID}", apiCfg.middlewareAuth(apiCfg.handlerUnpinChirp))
+	serverMux.HandleFunc("DELETE /api/users/me/sessions", apiCfg.middlewareAuth(apiCfg.handlerRevokeOtherSessions))
+	serverMux.HandleFunc("DELETE /api/users/me/sessions/{sessionID}", apiCfg.middlewareAuth(apiCfg.handlerRevokeSession))
 
 	serverS := http.Server{
 		Handler: serverMux,
 		Addr:    ":" + port,
 	}
 
+	go apiCfg.runScheduledPublisher(context.Background(), scheduledPublishInterval)
+	go apiCfg.runTrashPurger(context.Background(), trashPurgeInterval)
+
 	serverS.ListenAndServe()
 }
 
@@ -89,3 +248,32 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
+
+// pathUnder reports whether path is dir or somewhere beneath it, following
+// symlinks for whichever of them already exist.
+func pathUnder(path, dir string) (bool, error) {
+	path, err := resolvePath(path)
+	if err != nil {
+		return false, err
+	}
+	dir, err = resolvePath(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false, err
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+func resolvePath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, nil
+	}
+	return path, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultPageLimit = 20
+const maxPageLimit = 100
+
+// pageCursor marks a position in a (created_at, id) ordered listing. Prev
+// is set on cursors that page back towards the start of the listing. Start
+// marks the very start of a listing whose first page held only extra items
+// shown ahead of it, such as pinned chirps.
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Prev      bool      `json:"p,omitempty"`
+	Start     bool      `json:"s,omitempty"`
+}
+
+type pageParams struct {
+	limit  int
+	cursor *pageCursor
+}
+
+// encodeCursor turns a cursor value into the opaque string handed to clients.
+func encodeCursor(c any) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string, c any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return errors.New("malformed cursor")
+	}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return errors.New("malformed cursor")
+	}
+	return nil
+}
+
+func parsePageLimit(query url.Values) (int, error) {
+	limit := query.Get("limit")
+	if limit == "" {
+		return defaultPageLimit, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return min(n, maxPageLimit), nil
+}
+
+func parsePageParams(query url.Values) (pageParams, error) {
+	params := pageParams{}
+
+	limit, err := parsePageLimit(query)
+	if err != nil {
+		return params, err
+	}
+	params.limit = limit
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		c := pageCursor{}
+		err := decodeCursor(cursor, &c)
+		if err != nil || (c.ID == uuid.Nil && !c.Start) {
+			return params, errors.New("malformed cursor")
+		}
+		params.cursor = &c
+	}
+
+	return params, nil
+}
+
+// backward reports whether the page is being fetched towards the start of
+// the listing, in which case the rows arrive in reverse display order.
+func (p pageParams) backward() bool {
+	return p.cursor != nil && p.cursor.Prev
+}
+
+// fetchLimit asks for one row more than the page size so the caller can
+// tell whether another page exists past this one.
+func (p pageParams) fetchLimit() int32 {
+	return int32(p.limit + 1)
+}
+
+// buildPage trims the lookahead row, restores display order for backward
+// fetches and returns the cursors for the neighbouring pages, if any.
+func buildPage[T any](rows []T, p pageParams, key func(T) (time.Time, uuid.UUID)) ([]T, string, string) {
+	more := len(rows) > p.limit
+	if more {
+		rows = rows[:p.limit]
+	}
+	if p.backward() {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	hasNext, hasPrev := more, p.cursor != nil
+	if p.backward() {
+		hasNext, hasPrev = true, more
+	}
+
+	next, prev := "", ""
+	if hasNext {
+		createdAt, id := key(rows[len(rows)-1])
+		next = encodeCursor(pageCursor{CreatedAt: createdAt, ID: id})
+	}
+	if hasPrev {
+		createdAt, id := key(rows[0])
+		prev = encodeCursor(pageCursor{CreatedAt: createdAt, ID: id, Prev: true})
+	}
+	return rows, next, prev
+}
+
+// setLinkHeader advertises the neighbouring pages as RFC 8288 links,
+// keeping every other query parameter of the current request.
+func setLinkHeader(w http.ResponseWriter, r *http.Request, next, prev string) {
+	links := []string{}
+	for _, l := range []struct{ rel, cursor string }{{"next", next}, {"prev", prev}} {
+		if l.cursor == "" {
+			continue
+		}
+		query := r.URL.Query()
+		query.Set("cursor", l.cursor)
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), l.rel))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
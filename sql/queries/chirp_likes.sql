@@ -0,0 +1,43 @@
+-- name: CreateChirpLike :execrows
+INSERT INTO chirp_likes(user_id, chirp_id, created_at)
+VALUES(
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpLike :execrows
+DELETE FROM chirp_likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: IncrementLikeCount :one
+UPDATE chirps
+SET like_count = like_count + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: DecrementLikeCount :one
+UPDATE chirps
+SET like_count = like_count - 1
+WHERE id = $1
+RETURNING *;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = $1 AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetLikedChirps :many
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN chirps ON chirps.id = chirp_likes.chirp_id
+WHERE chirp_likes.user_id = sqlc.arg('user_id')
+AND chirps.tombstoned_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirp_listed_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+AND (
+    sqlc.narg('cursor_liked_at')::timestamp IS NULL
+    OR (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg('cursor_liked_at')::timestamp, sqlc.narg('cursor_chirp_id')::uuid)
+)
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,35 @@
+-- name: LockUserPins :exec
+SELECT id FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountPins :one
+SELECT COUNT(*) FROM chirp_pins
+WHERE user_id = $1;
+
+-- name: CreatePin :execrows
+INSERT INTO chirp_pins(user_id, chirp_id, created_at)
+VALUES(
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePin :execrows
+DELETE FROM chirp_pins
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: DeleteChirpPins :exec
+DELETE FROM chirp_pins
+WHERE chirp_id = $1;
+
+-- name: GetPinnedChirps :many
+SELECT chirps.*
+FROM chirp_pins
+JOIN chirps ON chirps.id = chirp_pins.chirp_id
+WHERE chirp_pins.user_id = sqlc.arg('user_id')
+AND chirps.tombstoned_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirp_listed_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+ORDER BY chirp_pins.created_at DESC, chirp_pins.chirp_id DESC;
@@ -0,0 +1,60 @@
+-- name: IncrementReplyCount :execrows
+UPDATE chirps
+SET reply_count = reply_count + 1
+WHERE id = $1 AND tombstoned_at IS NULL AND deleted_at IS NULL AND rechirp_of IS NULL;
+
+-- name: DecrementReplyCount :one
+UPDATE chirps
+SET reply_count = reply_count - 1
+WHERE id = $1
+RETURNING *;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', tombstoned_at = NOW(), deleted_at = NULL, rechirp_count = 0
+WHERE id = $1;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT c.id, c.in_reply_to, 0 AS depth
+    FROM chirps AS c
+    WHERE c.id = $1
+    UNION ALL
+    SELECT c.id, c.in_reply_to, a.depth + 1
+    FROM chirps AS c
+    JOIN ancestors AS a ON c.id = a.in_reply_to
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpReplies :many
+SELECT * FROM chirps
+WHERE in_reply_to = sqlc.arg('parent_id')
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetReplyDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT c.id, 1 AS depth
+    FROM chirps AS c
+    WHERE c.in_reply_to = ANY(sqlc.arg('parent_ids')::uuid[])
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+    UNION ALL
+    SELECT c.id, d.depth + 1
+    FROM chirps AS c
+    JOIN descendants AS d ON c.in_reply_to = d.id
+    WHERE d.depth < sqlc.arg('max_depth')::int
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+)
+SELECT chirps.* FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,29 @@
+-- name: GetChirpByIdForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET updated_at = NOW(), body = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions(id, created_at, chirp_id, body)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions
+WHERE chirp_id = $1;
@@ -0,0 +1,45 @@
+-- name: TrashChirp :exec
+UPDATE chirps
+SET deleted_at = $2
+WHERE id = $1;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: GetTrashedChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND deleted_at IS NOT NULL
+AND (
+    sqlc.narg('cursor_deleted_at')::timestamp IS NULL
+    OR (deleted_at, id) < (sqlc.narg('cursor_deleted_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ClaimExpiredTrashedChirp :one
+SELECT * FROM chirps
+WHERE deleted_at < $1
+AND NOT EXISTS (
+    SELECT 1 FROM trash_purge_failures
+    WHERE trash_purge_failures.chirp_id = chirps.id AND trash_purge_failures.retry_at > NOW()
+)
+ORDER BY deleted_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: RecordTrashPurgeFailure :exec
+INSERT INTO trash_purge_failures(chirp_id, attempts, failed_at, retry_at, failure)
+VALUES($1, 1, NOW(), NOW() + INTERVAL '1 minute', $2)
+ON CONFLICT (chirp_id) DO UPDATE
+SET attempts = trash_purge_failures.attempts + 1,
+    failed_at = NOW(),
+    retry_at = NOW() + LEAST(power(2, trash_purge_failures.attempts) * INTERVAL '1 minute', INTERVAL '1 day'),
+    failure = EXCLUDED.failure;
+
+-- name: ClearTrashPurgeFailure :exec
+DELETE FROM trash_purge_failures
+WHERE chirp_id = $1;
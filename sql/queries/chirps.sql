@@ -1,10 +1,23 @@
 -- name: CreateChirp :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, quote_of, visibility)
 VALUES(
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetVisibleChirpIDs :many
+SELECT id FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id'));
+
+-- name: GetListedChirpIDs :many
+SELECT id FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND chirp_listed_to(id, user_id, visibility, sqlc.narg('viewer_id'));
@@ -0,0 +1,39 @@
+-- name: GetChirpsPageAsc :many
+SELECT * FROM chirps
+WHERE tombstoned_at IS NULL
+AND deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND chirp_listed_to(id, user_id, visibility, sqlc.narg('viewer_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+AND (
+    sqlc.narg('exclude_pinned_by')::uuid IS NULL
+    OR NOT EXISTS (
+        SELECT 1 FROM chirp_pins
+        WHERE chirp_pins.user_id = sqlc.narg('exclude_pinned_by')::uuid AND chirp_pins.chirp_id = chirps.id
+    )
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsPageDesc :many
+SELECT * FROM chirps
+WHERE tombstoned_at IS NULL
+AND deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND chirp_listed_to(id, user_id, visibility, sqlc.narg('viewer_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+AND (
+    sqlc.narg('exclude_pinned_by')::uuid IS NULL
+    OR NOT EXISTS (
+        SELECT 1 FROM chirp_pins
+        WHERE chirp_pins.user_id = sqlc.narg('exclude_pinned_by')::uuid AND chirp_pins.chirp_id = chirps.id
+    )
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
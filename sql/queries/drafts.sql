@@ -0,0 +1,43 @@
+-- name: CreateDraft :one
+INSERT INTO drafts(id, created_at, updated_at, user_id, body, in_reply_to, quote_of, media_ids, visibility)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetDraftForUpdate :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: GetDrafts :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_updated_at')::timestamp IS NULL
+    OR (updated_at, id) < (sqlc.narg('cursor_updated_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET updated_at = NOW(), body = $1, in_reply_to = $2, quote_of = $3, media_ids = $4, visibility = $5
+WHERE id = $6 AND user_id = $7
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,69 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows(follower_id, followee_id, created_at)
+VALUES(
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: UpdateFollowCounts :exec
+UPDATE users
+SET following_count = following_count + CASE WHEN id = sqlc.arg('follower_id') THEN sqlc.arg('delta')::integer ELSE 0 END,
+    follower_count = follower_count + CASE WHEN id = sqlc.arg('followee_id') THEN sqlc.arg('delta')::integer ELSE 0 END
+WHERE id IN (sqlc.arg('follower_id'), sqlc.arg('followee_id'));
+
+-- name: GetFollowers :many
+SELECT users.id, users.username, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_followed_at')::timestamp IS NULL
+    OR (follows.created_at, follows.follower_id) < (sqlc.narg('cursor_followed_at')::timestamp, sqlc.narg('cursor_user_id')::uuid)
+)
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowing :many
+SELECT users.id, users.username, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_followed_at')::timestamp IS NULL
+    OR (follows.created_at, follows.followee_id) < (sqlc.narg('cursor_followed_at')::timestamp, sqlc.narg('cursor_user_id')::uuid)
+)
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTimeline :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT recent.id
+    FROM (
+        SELECT follows.followee_id AS author_id FROM follows
+        WHERE follows.follower_id = sqlc.arg('user_id')
+        UNION ALL
+        SELECT sqlc.arg('user_id')::uuid
+    ) AS authors
+    CROSS JOIN LATERAL (
+        SELECT c.id FROM chirps AS c
+        WHERE c.user_id = authors.author_id
+        AND c.tombstoned_at IS NULL
+        AND c.deleted_at IS NULL
+        AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.arg('user_id'))
+        AND (
+            sqlc.narg('cursor_created_at')::timestamp IS NULL
+            OR (c.created_at, c.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+        )
+        ORDER BY c.created_at DESC, c.id DESC
+        LIMIT sqlc.arg('limit')
+    ) AS recent
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,45 @@
+-- name: UpsertHashtags :exec
+INSERT INTO hashtags(id, tag)
+SELECT gen_random_uuid(), unnest(sqlc.arg('tags')::text[])
+ON CONFLICT (tag) DO NOTHING;
+
+-- name: TagChirp :exec
+INSERT INTO chirp_hashtags(chirp_id, hashtag_id, created_at)
+SELECT sqlc.arg('chirp_id'), hashtags.id, sqlc.arg('created_at')
+FROM hashtags
+WHERE hashtags.tag = ANY(sqlc.arg('tags')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: GetHashtagChirps :many
+SELECT sqlc.embed(chirps) FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+AND chirps.tombstoned_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirp_listed_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirp_hashtags.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirp_hashtags.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetHashtagCounts :many
+SELECT
+    hashtags.tag,
+    COUNT(*) FILTER (WHERE chirp_hashtags.created_at >= sqlc.arg('window_start')::timestamp) AS recent_count,
+    COUNT(*) FILTER (WHERE chirp_hashtags.created_at < sqlc.arg('window_start')::timestamp) AS baseline_count
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at >= sqlc.arg('baseline_start')::timestamp
+AND chirps.tombstoned_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirps.visibility = 'public'
+GROUP BY hashtags.tag
+HAVING COUNT(*) FILTER (WHERE chirp_hashtags.created_at >= sqlc.arg('window_start')::timestamp) >= sqlc.arg('min_count')::bigint;
@@ -0,0 +1,65 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files(id, created_at, user_id, content_hash, content_type, size_bytes, width, height, thumbnail_hash, alt_text)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetMediaFileByID :one
+SELECT * FROM media_files
+WHERE id = $1;
+
+-- name: GetMediaFileByHash :one
+SELECT * FROM media_files
+WHERE content_hash = $1
+LIMIT 1;
+
+-- name: UpdateMediaAltText :one
+UPDATE media_files
+SET alt_text = $1
+WHERE id = $2 AND user_id = $3
+RETURNING *;
+
+-- name: AttachMedia :execrows
+INSERT INTO chirp_attachments(chirp_id, media_id, position)
+SELECT sqlc.arg('chirp_id'), media_files.id, ids.ord - 1
+FROM unnest(sqlc.arg('media_ids')::uuid[]) WITH ORDINALITY AS ids(id, ord)
+JOIN media_files ON media_files.id = ids.id
+WHERE media_files.user_id = sqlc.arg('user_id')
+AND NOT EXISTS (
+    SELECT 1 FROM chirp_attachments WHERE chirp_attachments.media_id = media_files.id
+);
+
+-- name: DetachChirpMedia :exec
+DELETE FROM chirp_attachments
+WHERE chirp_id = $1;
+
+-- name: GetMediaForChirps :many
+SELECT chirp_attachments.chirp_id, sqlc.embed(media_files)
+FROM chirp_attachments
+JOIN media_files ON media_files.id = chirp_attachments.media_id
+WHERE chirp_attachments.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_attachments.chirp_id, chirp_attachments.position;
+
+-- name: GetMediaChirp :one
+SELECT chirps.*
+FROM chirp_attachments
+JOIN chirps ON chirps.id = chirp_attachments.chirp_id
+WHERE chirp_attachments.media_id = $1;
+
+-- name: CountAttachableMedia :one
+SELECT COUNT(*) FROM media_files
+WHERE id = ANY(sqlc.arg('media_ids')::uuid[])
+AND user_id = sqlc.arg('user_id')
+AND NOT EXISTS (
+    SELECT 1 FROM chirp_attachments WHERE chirp_attachments.media_id = media_files.id
+);
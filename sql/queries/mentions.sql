@@ -0,0 +1,38 @@
+-- name: GetUsersByUsernames :many
+SELECT id, username FROM users
+WHERE lower(username) = ANY(sqlc.arg('usernames')::text[]);
+
+-- name: CreateChirpMentions :exec
+INSERT INTO chirp_mentions(chirp_id, user_id, start_offset, end_offset)
+SELECT
+    sqlc.arg('chirp_id'),
+    unnest(sqlc.arg('user_ids')::uuid[]),
+    unnest(sqlc.arg('start_offsets')::int[]),
+    unnest(sqlc.arg('end_offsets')::int[]);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: GetMentionsForChirps :many
+SELECT chirp_mentions.chirp_id, chirp_mentions.user_id, users.username, chirp_mentions.start_offset, chirp_mentions.end_offset
+FROM chirp_mentions
+JOIN users ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_mentions.chirp_id, chirp_mentions.start_offset;
+
+-- name: GetMentioningChirps :many
+SELECT sqlc.embed(chirps) FROM chirps
+WHERE EXISTS (
+    SELECT 1 FROM chirp_mentions
+    WHERE chirp_mentions.chirp_id = chirps.id AND chirp_mentions.user_id = sqlc.arg('user_id')
+)
+AND chirps.tombstoned_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
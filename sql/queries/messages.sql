@@ -0,0 +1,123 @@
+-- name: CreateConversation :one
+INSERT INTO conversations(id, created_at, updated_at)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: AddConversationParticipants :execrows
+INSERT INTO conversation_participants(conversation_id, user_id, joined_at)
+SELECT sqlc.arg('conversation_id'), users.id, NOW()
+FROM users
+WHERE users.id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: LockConversationParticipants :exec
+SELECT pg_advisory_xact_lock(hashtextextended(array_to_string(sqlc.arg('user_ids')::uuid[], ','), 0));
+
+-- name: FindConversationByParticipants :one
+SELECT conversations.* FROM conversations
+WHERE conversations.id IN (
+    SELECT conversation_id FROM conversation_participants
+    WHERE conversation_participants.user_id = sqlc.arg('user_id')
+)
+AND (
+    SELECT array_agg(conversation_participants.user_id ORDER BY conversation_participants.user_id)
+    FROM conversation_participants
+    WHERE conversation_participants.conversation_id = conversations.id
+) = sqlc.arg('user_ids')::uuid[]
+ORDER BY conversations.created_at
+LIMIT 1;
+
+-- name: GetConversation :one
+SELECT sqlc.embed(conversations), (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND (
+        conversation_participants.last_read_message_id IS NULL
+        OR (messages.created_at, messages.id) > (conversation_participants.last_read_at, conversation_participants.last_read_message_id)
+    )
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg('id') AND conversation_participants.user_id = sqlc.arg('user_id');
+
+-- name: GetConversations :many
+SELECT sqlc.embed(conversations), (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND (
+        conversation_participants.last_read_message_id IS NULL
+        OR (messages.created_at, messages.id) > (conversation_participants.last_read_at, conversation_participants.last_read_message_id)
+    )
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_updated_at')::timestamp IS NULL
+    OR (conversations.updated_at, conversations.id) < (sqlc.narg('cursor_updated_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetConversationParticipants :many
+SELECT conversation_participants.conversation_id, conversation_participants.user_id, users.username, conversation_participants.last_read_message_id
+FROM conversation_participants
+JOIN users ON users.id = conversation_participants.user_id
+WHERE conversation_participants.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_participants.conversation_id, conversation_participants.joined_at, conversation_participants.user_id;
+
+-- name: IsConversationParticipant :one
+SELECT EXISTS(
+    SELECT 1 FROM conversation_participants
+    WHERE conversation_id = $1 AND user_id = $2
+);
+
+-- name: CreateMessage :one
+INSERT INTO messages(id, created_at, conversation_id, sender_id, body)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = $2
+WHERE id = $1;
+
+-- name: GetMessage :one
+SELECT * FROM messages
+WHERE id = $1 AND conversation_id = $2;
+
+-- name: GetLatestMessage :one
+SELECT * FROM messages
+WHERE conversation_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT 1;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg('conversation_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants
+SET last_read_at = sqlc.arg('last_read_at'), last_read_message_id = sqlc.arg('last_read_message_id')
+WHERE conversation_id = sqlc.arg('conversation_id') AND user_id = sqlc.arg('user_id')
+AND (
+    last_read_message_id IS NULL
+    OR (last_read_at, last_read_message_id) < (sqlc.arg('last_read_at')::timestamp, sqlc.arg('last_read_message_id')::uuid)
+);
@@ -0,0 +1,53 @@
+-- name: GetModerationRules :many
+SELECT * FROM moderation_rules
+ORDER BY created_at, id;
+
+-- name: CreateModerationRule :one
+INSERT INTO moderation_rules(id, created_at, pattern, action)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules
+WHERE id = $1;
+
+-- name: CreateHeldChirp :one
+INSERT INTO held_chirps(id, created_at, user_id, chirp_id, body, in_reply_to, quote_of, reason, media_ids, visibility, poll_options, poll_closes_at)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
+)
+RETURNING *;
+
+-- name: GetHeldChirps :many
+SELECT * FROM held_chirps
+WHERE (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: GetHeldChirpForUpdate :one
+SELECT * FROM held_chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: DeleteHeldChirp :execrows
+DELETE FROM held_chirps
+WHERE id = $1;
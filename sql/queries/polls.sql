@@ -0,0 +1,47 @@
+-- name: CreatePoll :exec
+INSERT INTO polls(chirp_id, closes_at)
+VALUES($1, $2);
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_options(chirp_id, position, text)
+SELECT sqlc.arg('chirp_id'), options.ord - 1, options.text
+FROM unnest(sqlc.arg('options')::text[]) WITH ORDINALITY AS options(text, ord);
+
+-- name: DeletePoll :exec
+DELETE FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPollsForChirps :many
+SELECT polls.chirp_id, polls.closes_at, poll_options.position, poll_options.text, poll_options.vote_count
+FROM polls
+JOIN poll_options ON poll_options.chirp_id = polls.chirp_id
+WHERE polls.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY polls.chirp_id, poll_options.position;
+
+-- name: GetPollForUpdate :one
+SELECT * FROM polls
+WHERE chirp_id = $1
+FOR UPDATE;
+
+-- name: CountPollOptions :one
+SELECT COUNT(*) FROM poll_options
+WHERE chirp_id = $1;
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes(chirp_id, user_id, position, created_at)
+VALUES(
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: IncrementPollVoteCount :exec
+UPDATE poll_options
+SET vote_count = vote_count + 1
+WHERE chirp_id = $1 AND position = $2;
+
+-- name: GetPollVotes :many
+SELECT chirp_id, position FROM poll_votes
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
@@ -0,0 +1,17 @@
+-- name: GetUserByUsername :one
+SELECT * FROM users
+WHERE lower(username) = lower($1);
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET updated_at = NOW(),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url)
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: CountUserChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND tombstoned_at IS NULL AND deleted_at IS NULL
+AND visibility = 'public';
@@ -0,0 +1,42 @@
+-- name: CreateRechirp :one
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, rechirp_of, visibility)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    '',
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: IncrementRechirpCount :exec
+UPDATE chirps
+SET rechirp_count = rechirp_count + 1
+WHERE id = $1;
+
+-- name: DecrementRechirpCount :exec
+UPDATE chirps
+SET rechirp_count = rechirp_count - 1
+WHERE id = $1;
+
+-- name: IncrementQuoteCount :exec
+UPDATE chirps
+SET quote_count = quote_count + 1
+WHERE id = $1;
+
+-- name: DecrementQuoteCount :one
+UPDATE chirps
+SET quote_count = quote_count - 1
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of = $1;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
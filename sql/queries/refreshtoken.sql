@@ -1,11 +1,54 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens(token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens(token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, user_agent, ip, last_used_at)
 VALUES (
     $1,
     NOW(),
     NOW(),
     $2,
     $3,
-    NULL
+    NULL,
+    $4,
+    $5,
+    $6,
+    NOW()
 )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetRefreshTokenForUpdate :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: RetireRefreshToken :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), retired_at = NOW(), replaced_by = $2
+WHERE token_hash = $1;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: GetSessions :many
+SELECT family_id, (
+    SELECT MIN(family.created_at) FROM refresh_tokens AS family
+    WHERE family.family_id = refresh_tokens.family_id
+)::timestamp AS started_at, user_agent, ip, last_used_at, expires_at
+FROM refresh_tokens
+WHERE user_id = $1
+AND revoked_at IS NULL
+AND retired_at IS NULL
+AND expires_at > NOW()
+ORDER BY last_used_at DESC, family_id;
+
+-- name: RevokeUserRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET updated_at = NOW(), revoked_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeOtherRefreshTokenFamilies :execrows
+UPDATE refresh_tokens
+SET updated_at = NOW(), revoked_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND revoked_at IS NULL
+AND family_id <> sqlc.arg('keep_family_id');
\ No newline at end of file
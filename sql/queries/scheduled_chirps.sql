@@ -0,0 +1,40 @@
+-- name: CreateScheduledChirp :one
+INSERT INTO scheduled_chirps(id, created_at, user_id, body, in_reply_to, quote_of, media_ids, publish_at, visibility)
+VALUES(
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetScheduledChirps :many
+SELECT * FROM scheduled_chirps
+WHERE user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_publish_at')::timestamp IS NULL
+    OR (publish_at, id) > (sqlc.narg('cursor_publish_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY publish_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM scheduled_chirps
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimDueScheduledChirp :one
+SELECT * FROM scheduled_chirps
+WHERE publish_at <= $1 AND failed_at IS NULL
+ORDER BY publish_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkScheduledChirpFailed :exec
+UPDATE scheduled_chirps
+SET failed_at = NOW(), failure = $2
+WHERE id = $1 AND failed_at IS NULL;
@@ -0,0 +1,26 @@
+-- name: SearchChirps :many
+SELECT
+    sqlc.embed(chirps),
+    ts_rank(body_tsv, to_tsquery('english', sqlc.arg('query')))::real AS rank,
+    ts_headline(
+        'english',
+        replace(replace(replace(replace(replace(body,
+            '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+        to_tsquery('english', sqlc.arg('query')),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=3, FragmentDelimiter=" ... "'
+    )::text AS snippet
+FROM chirps
+WHERE body_tsv @@ to_tsquery('english', sqlc.arg('query'))
+AND tombstoned_at IS NULL
+AND deleted_at IS NULL
+AND chirp_listed_to(id, user_id, visibility, sqlc.narg('viewer_id'))
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (
+    sqlc.narg('cursor_rank')::real IS NULL
+    OR (ts_rank(body_tsv, to_tsquery('english', sqlc.arg('query'))), created_at, id)
+        < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
@@ -1,4 +1,16 @@
 -- name: UpdateUser :exec
 UPDATE users
-SET updated_at = NOW(), email = $1, hashed_password = $2
-WHERE id = $3;
\ No newline at end of file
+SET updated_at = NOW(),
+    email = COALESCE(sqlc.narg('email'), email),
+    hashed_password = COALESCE(sqlc.narg('hashed_password'), hashed_password)
+WHERE id = sqlc.arg('id');
+
+-- name: UpdateUsername :exec
+UPDATE users
+SET updated_at = NOW(), username = $1
+WHERE id = $2;
+
+-- name: UpgradePasswordHash :exec
+UPDATE users
+SET hashed_password = sqlc.arg('new_hash')
+WHERE id = sqlc.arg('id') AND hashed_password = sqlc.arg('old_hash');
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN rechirp_of UUID REFERENCES chirps ON DELETE CASCADE,
+ADD COLUMN quote_of UUID REFERENCES chirps ON DELETE SET NULL,
+ADD COLUMN rechirp_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN quote_count INTEGER NOT NULL DEFAULT 0,
+ADD CONSTRAINT chirps_rechirp_or_quote CHECK (rechirp_of IS NULL OR quote_of IS NULL);
+
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_idx ON chirps (user_id, rechirp_of)
+WHERE rechirp_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_of_idx;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_rechirp_or_quote,
+DROP COLUMN quote_count,
+DROP COLUMN rechirp_count,
+DROP COLUMN quote_of,
+DROP COLUMN rechirp_of;
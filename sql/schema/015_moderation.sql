@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE moderation_rules(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('censor', 'hold', 'reject'))
+);
+
+CREATE UNIQUE INDEX moderation_rules_pattern_lower_idx ON moderation_rules (lower(pattern));
+
+INSERT INTO moderation_rules(id, created_at, pattern, action)
+VALUES
+    (gen_random_uuid(), NOW(), 'kerfuffle', 'censor'),
+    (gen_random_uuid(), NOW(), 'sharbert', 'censor'),
+    (gen_random_uuid(), NOW(), 'fornax', 'censor');
+
+CREATE TABLE held_chirps(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    in_reply_to UUID,
+    quote_of UUID,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX held_chirps_created_at_id_idx ON held_chirps (created_at, id);
+
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_admin;
+
+DROP TABLE held_chirps;
+
+DROP TABLE moderation_rules;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE media_files(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    content_hash TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    thumbnail_hash TEXT,
+    alt_text TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX media_files_content_hash_idx ON media_files (content_hash);
+
+CREATE TABLE chirp_attachments(
+    chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+    media_id UUID NOT NULL UNIQUE REFERENCES media_files ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, position)
+);
+
+ALTER TABLE held_chirps
+ADD COLUMN media_ids UUID[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE held_chirps
+DROP COLUMN media_ids;
+
+DROP TABLE chirp_attachments;
+
+DROP TABLE media_files;
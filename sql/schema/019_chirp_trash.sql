@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN deleted_at TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX chirps_trash_idx ON chirps (user_id, deleted_at, id) WHERE deleted_at IS NOT NULL;
+CREATE INDEX chirps_trash_purge_idx ON chirps (deleted_at, id) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_trash_purge_idx;
+DROP INDEX chirps_trash_idx;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
@@ -0,0 +1,64 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'unlisted', 'followers', 'mentioned', 'private'));
+
+ALTER TABLE held_chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+ALTER TABLE scheduled_chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+ALTER TABLE drafts
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+-- chirp_visible_to reports whether viewer may read a chirp. viewer is NULL
+-- for anonymous requests, which only ever see public chirps.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(target_id UUID, target_author UUID, target_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT target_visibility = 'public'
+        OR (viewer IS NOT NULL AND (
+            target_author = viewer
+            OR target_visibility = 'unlisted'
+            OR (target_visibility = 'followers' AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.follower_id = viewer AND follows.followee_id = target_author
+            ))
+            OR (target_visibility IN ('followers', 'mentioned') AND EXISTS (
+                SELECT 1 FROM chirp_mentions
+                WHERE chirp_mentions.chirp_id = target_id AND chirp_mentions.user_id = viewer
+            ))
+        ))
+$$;
+-- +goose StatementEnd
+
+-- chirp_listed_to is chirp_visible_to for listings, which leave out unlisted
+-- chirps other than the viewer's own.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_listed_to(target_id UUID, target_author UUID, target_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT (target_visibility <> 'unlisted' OR target_author = viewer) IS TRUE
+        AND chirp_visible_to(target_id, target_author, target_visibility, viewer)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_listed_to;
+DROP FUNCTION chirp_visible_to;
+
+ALTER TABLE drafts
+DROP COLUMN visibility;
+
+ALTER TABLE scheduled_chirps
+DROP COLUMN visibility;
+
+ALTER TABLE held_chirps
+DROP COLUMN visibility;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
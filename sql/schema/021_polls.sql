@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE polls(
+    chirp_id UUID PRIMARY KEY REFERENCES chirps ON DELETE CASCADE,
+    closes_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE poll_options(
+    chirp_id UUID NOT NULL REFERENCES polls ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    vote_count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (chirp_id, position)
+);
+
+CREATE TABLE poll_votes(
+    chirp_id UUID NOT NULL REFERENCES polls ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id),
+    FOREIGN KEY (chirp_id, position) REFERENCES poll_options (chirp_id, position) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_user_id_idx ON poll_votes (user_id, chirp_id);
+
+ALTER TABLE held_chirps
+ADD COLUMN poll_options TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN poll_closes_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE held_chirps
+DROP COLUMN poll_closes_at,
+DROP COLUMN poll_options;
+
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE chirp_pins(
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX chirp_pins_chirp_id_idx ON chirp_pins (chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_pins;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Refresh tokens are now stored as a keyed hash. The key never reaches the
+-- database, so existing plaintext tokens cannot be re-hashed here; they are
+-- dropped instead and their users log in again.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token TO token_hash;
+
+-- +goose Down
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token_hash TO token;
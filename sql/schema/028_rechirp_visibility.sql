@@ -0,0 +1,10 @@
+-- +goose Up
+-- Rechirps take the visibility of the chirp they share, so rechirping an
+-- unlisted chirp does not put it in listings.
+UPDATE chirps
+SET visibility = originals.visibility
+FROM chirps AS originals
+WHERE chirps.rechirp_of = originals.id
+AND chirps.visibility <> originals.visibility;
+
+-- +goose Down
@@ -0,0 +1,13 @@
+-- +goose Up
+-- A trashed chirp that fails to purge is retried with a growing delay, and
+-- skipped until then, so it cannot hold up the chirps behind it.
+CREATE TABLE trash_purge_failures(
+    chirp_id UUID PRIMARY KEY REFERENCES chirps ON DELETE CASCADE,
+    attempts INTEGER NOT NULL,
+    failed_at TIMESTAMP NOT NULL,
+    retry_at TIMESTAMP NOT NULL,
+    failure TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE trash_purge_failures;
@@ -1,20 +1,38 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
-	"github.com/Chase-Outman/GitLab/internal/auth"
 	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/Chase-Outman/GitLab/internal/entities"
 	"github.com/google/uuid"
 )
 
+// parseUsername validates an optional username. An empty string means no
+// username.
+func parseUsername(username string) (sql.NullString, error) {
+	if username == "" {
+		return sql.NullString{}, nil
+	}
+	if !entities.ValidUsername(username) {
+		return sql.NullString{}, errors.New("username must be 3-30 letters, digits or underscores")
+	}
+	return sql.NullString{String: username, Valid: true}, nil
+}
+
 func (cfg *apiConfig) handlerUpdateUsers(w http.ResponseWriter, r *http.Request) {
 	type userParams struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    string  `json:"password"`
+		Email       string  `json:"email"`
+		Username    *string `json:"username"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -26,30 +44,103 @@ func (cfg *apiConfig) handlerUpdateUsers(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(userP.Password)
+	err = validateProfile(userP.DisplayName, userP.Bio, userP.AvatarURL)
 	if err != nil {
-		log.Printf("Error hashing password: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	err = cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		Email:          userP.Email,
-		HashedPassword: hashedPassword,
-		ID:             r.Context().Value("userID").(uuid.UUID),
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	tx, err := cfg.sqlDB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Email and password are each only replaced when the request carries
+	// them, so changing one does not need the other.
+	if userP.Email != "" || userP.Password != "" {
+		hashedPassword := sql.NullString{}
+		if userP.Password != "" {
+			hash, err := cfg.passwords.Hash(userP.Password)
+			if err != nil {
+				log.Printf("Error hashing password: %s", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			hashedPassword = sql.NullString{String: hash, Valid: true}
+		}
+
+		err = qtx.UpdateUser(r.Context(), database.UpdateUserParams{
+			Email:          sql.NullString{String: userP.Email, Valid: userP.Email != ""},
+			HashedPassword: hashedPassword,
+			ID:             userID,
+		})
+		if err != nil {
+			log.Printf("Error updating user: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if userP.Username != nil {
+		username, err := parseUsername(*userP.Username)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		err = qtx.UpdateUsername(r.Context(), database.UpdateUsernameParams{
+			Username: username,
+			ID:       userID,
+		})
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "username is already taken")
+			return
+		}
+		if err != nil {
+			log.Printf("Error updating username: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	user, err := qtx.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		DisplayName: nullString(userP.DisplayName),
+		Bio:         nullString(userP.Bio),
+		AvatarUrl:   nullString(userP.AvatarURL),
+		ID:          userID,
 	})
 	if err != nil {
-		log.Printf("Error updating user: %v", err)
+		log.Printf("Error updating profile: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing user update: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	profile, err := cfg.userProfile(r.Context(), user)
+	if err != nil {
+		log.Printf("Error loading profile: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
 	type userReturnVals struct {
+		UserProfile
 		Email string `json:"email"`
 	}
 
 	resp := userReturnVals{
-		Email: userP.Email,
+		UserProfile: profile,
+		Email:       user.Email,
 	}
 
 	respondWithJSON(w, http.StatusOK, resp)
@@ -59,6 +150,7 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	type userParams struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
+		Username string `json:"username"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -71,7 +163,13 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(userP.Password)
+	username, err := parseUsername(userP.Username)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hashedPassword, err := cfg.passwords.Hash(userP.Password)
 	if err != nil {
 		log.Printf("Error hashing password: %s", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -81,7 +179,12 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          userP.Email,
 		HashedPassword: hashedPassword,
+		Username:       username,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "username is already taken")
+		return
+	}
 	if err != nil {
 		log.Printf("Error create new user: %s", err)
 		w.WriteHeader(500)
@@ -94,6 +197,7 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		Updated_at  time.Time `json:"updated_at"`
 		Email       string    `json:"email"`
 		IsChirpyRed bool      `json:"is_chirpy_red"`
+		Username    string    `json:"username,omitempty"`
 	}
 
 	resp := userReturnVals{
@@ -102,6 +206,7 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		Updated_at:  user.UpdatedAt,
 		Email:       user.Email,
 		IsChirpyRed: user.IsChirpyRed,
+		Username:    user.Username.String,
 	}
 
 	respondWithJSON(w, 201, resp)
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Chase-Outman/GitLab/internal/auth"
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+// viewerID identifies the caller on endpoints that are public but show extra
+// state to signed in users. A missing or invalid token is treated as an
+// anonymous request rather than an error.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtKeys)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+// chirpBatch holds everything a batch of chirps needs beyond its own rows:
+// the chirps they rechirp or quote, their resolved mentions, attached media
+// and polls, and the viewer's flags and votes on all of them.
+// Loading it once per response keeps the lookups to one query each instead
+// of one per chirp. A batch loaded for a listing only embeds referenced
+// chirps the listing itself could show, so a public rechirp or quote cannot
+// carry an unlisted chirp into a feed.
+type chirpBatch struct {
+	viewer     uuid.NullUUID
+	liked      map[uuid.UUID]bool
+	bookmarked map[uuid.UUID]bool
+	referenced map[uuid.UUID]database.Chirp
+	mentions   map[uuid.UUID][]ChirpMention
+	media      map[uuid.UUID][]Media
+	polls      map[uuid.UUID][]database.GetPollsForChirpsRow
+	votes      map[uuid.UUID]int32
+}
+
+func (cfg *apiConfig) loadChirpBatch(ctx context.Context, viewer uuid.NullUUID, dbChirps []database.Chirp, listed bool) (chirpBatch, error) {
+	batch := chirpBatch{
+		viewer:     viewer,
+		liked:      map[uuid.UUID]bool{},
+		bookmarked: map[uuid.UUID]bool{},
+		referenced: map[uuid.UUID]database.Chirp{},
+		mentions:   map[uuid.UUID][]ChirpMention{},
+		media:      map[uuid.UUID][]Media{},
+		polls:      map[uuid.UUID][]database.GetPollsForChirpsRow{},
+		votes:      map[uuid.UUID]int32{},
+	}
+
+	ids := []uuid.UUID{}
+	refIDs := []uuid.UUID{}
+	for _, c := range dbChirps {
+		ids = append(ids, c.ID)
+		if c.RechirpOf.Valid {
+			refIDs = append(refIDs, c.RechirpOf.UUID)
+		}
+		if c.QuoteOf.Valid {
+			refIDs = append(refIDs, c.QuoteOf.UUID)
+		}
+	}
+
+	if len(refIDs) > 0 {
+		refs, err := cfg.db.GetChirpsByIDs(ctx, refIDs)
+		if err != nil {
+			return batch, err
+		}
+		// Only public and unlisted chirps can be shared, but an unlisted one
+		// is still hidden from anonymous viewers and from listings, so it is
+		// left unembedded there.
+		chirpIDs := visibleChirpIDs
+		if listed {
+			chirpIDs = listedChirpIDs
+		}
+		visible, err := chirpIDs(ctx, cfg.db, viewer, refIDs)
+		if err != nil {
+			return batch, err
+		}
+		for _, ref := range refs {
+			if !visible[ref.ID] {
+				continue
+			}
+			batch.referenced[ref.ID] = ref
+			ids = append(ids, ref.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return batch, nil
+	}
+
+	mentions, err := cfg.db.GetMentionsForChirps(ctx, ids)
+	if err != nil {
+		return batch, err
+	}
+	for _, m := range mentions {
+		batch.mentions[m.ChirpID] = append(batch.mentions[m.ChirpID], ChirpMention{
+			UserID:   m.UserID,
+			Username: m.Username.String,
+			Start:    m.StartOffset,
+			End:      m.EndOffset,
+		})
+	}
+
+	attachments, err := cfg.db.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return batch, err
+	}
+	for _, a := range attachments {
+		batch.media[a.ChirpID] = append(batch.media[a.ChirpID], mediaFromDB(a.MediaFile))
+	}
+
+	polls, err := cfg.db.GetPollsForChirps(ctx, ids)
+	if err != nil {
+		return batch, err
+	}
+	for _, p := range polls {
+		batch.polls[p.ChirpID] = append(batch.polls[p.ChirpID], p)
+	}
+
+	if !viewer.Valid {
+		return batch, nil
+	}
+
+	liked, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewer.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return batch, err
+	}
+	for _, id := range liked {
+		batch.liked[id] = true
+	}
+
+	bookmarked, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+		UserID:   viewer.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return batch, err
+	}
+	for _, id := range bookmarked {
+		batch.bookmarked[id] = true
+	}
+
+	if len(batch.polls) == 0 {
+		return batch, nil
+	}
+	votes, err := cfg.db.GetPollVotes(ctx, database.GetPollVotesParams{
+		UserID:   viewer.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return batch, err
+	}
+	for _, v := range votes {
+		batch.votes[v.ChirpID] = v.Position
+	}
+
+	return batch, nil
+}
+
+func (b chirpBatch) apply(chirp *Chirp) {
+	if mentions, ok := b.mentions[chirp.ID]; ok {
+		chirp.Mentions = mentions
+	}
+	if media, ok := b.media[chirp.ID]; ok {
+		chirp.Media = media
+	}
+	if rows, ok := b.polls[chirp.ID]; ok {
+		var myVote *int32
+		if vote, voted := b.votes[chirp.ID]; voted {
+			myVote = &vote
+		}
+		chirp.Poll = pollFromDB(rows, myVote, time.Now().UTC())
+	}
+
+	if !b.viewer.Valid {
+		return
+	}
+	liked := b.liked[chirp.ID]
+	chirp.LikedByMe = &liked
+	bookmarked := b.bookmarked[chirp.ID]
+	chirp.Bookmarked = &bookmarked
+}
+
+// convert turns a row into an API chirp, embedding the chirp it rechirps or
+// quotes one level deep.
+func (b chirpBatch) convert(dbChirp database.Chirp) Chirp {
+	chirp := b.single(dbChirp)
+	if dbChirp.DeletedAt.Valid {
+		return chirp
+	}
+
+	if ref, ok := b.referenced[dbChirp.RechirpOf.UUID]; ok && dbChirp.RechirpOf.Valid {
+		embedded := b.single(ref)
+		chirp.Rechirped = &embedded
+	}
+	if ref, ok := b.referenced[dbChirp.QuoteOf.UUID]; ok && dbChirp.QuoteOf.Valid {
+		embedded := b.single(ref)
+		chirp.Quoted = &embedded
+	}
+	return chirp
+}
+
+// single converts one row without embedding anything. A chirp in its owner's
+// trash shows as a tombstone wherever it is still referenced, such as in a
+// thread or a quote, until it is restored or purged.
+func (b chirpBatch) single(dbChirp database.Chirp) Chirp {
+	chirp := chirpFromDB(dbChirp)
+	if dbChirp.DeletedAt.Valid {
+		chirp.Body = ""
+		chirp.Deleted = true
+		return chirp
+	}
+	b.apply(&chirp)
+	return chirp
+}
+
+// chirpsForViewer converts database rows to API chirps with referenced chirps
+// embedded and the viewer's state filled in.
+func (cfg *apiConfig) chirpsForViewer(r *http.Request, dbChirps []database.Chirp) ([]Chirp, error) {
+	return cfg.convertForViewer(r, dbChirps, false)
+}
+
+// listedChirpsForViewer is chirpsForViewer for listings and feeds.
+func (cfg *apiConfig) listedChirpsForViewer(r *http.Request, dbChirps []database.Chirp) ([]Chirp, error) {
+	return cfg.convertForViewer(r, dbChirps, true)
+}
+
+func (cfg *apiConfig) convertForViewer(r *http.Request, dbChirps []database.Chirp, listed bool) ([]Chirp, error) {
+	batch, err := cfg.loadChirpBatch(r.Context(), cfg.viewerID(r), dbChirps, listed)
+	if err != nil {
+		return nil, err
+	}
+
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, batch.convert(dbChirp))
+	}
+	return chirps, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Chase-Outman/GitLab/internal/database"
+	"github.com/google/uuid"
+)
+
+// Who can read a chirp. The rules themselves live in the chirp_visible_to and
+// chirp_listed_to SQL functions so every query applies them the same way.
+const (
+	// visibilityPublic chirps are readable by anyone, signed in or not.
+	visibilityPublic = "public"
+	// visibilityUnlisted chirps are readable by any signed in user who has
+	// the link, but are left out of listings such as the global feed.
+	visibilityUnlisted = "unlisted"
+	// visibilityFollowers chirps are readable by the author's followers and
+	// anyone they mention.
+	visibilityFollowers = "followers"
+	// visibilityMentioned chirps are readable only by the users they mention.
+	visibilityMentioned = "mentioned"
+	// visibilityPrivate chirps are readable only by their author.
+	visibilityPrivate = "private"
+)
+
+var errInvalidVisibility = errors.New("visibility must be one of public, unlisted, followers, mentioned or private")
+
+// parseVisibility checks a requested visibility, defaulting to public.
+func parseVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityUnlisted, visibilityFollowers, visibilityMentioned, visibilityPrivate:
+		return visibility, nil
+	}
+	return "", errInvalidVisibility
+}
+
+// canRechirp reports whether a chirp may be rechirped or quoted. Only chirps
+// that anyone signed in can already read may be shared further.
+func canRechirp(chirp database.Chirp) bool {
+	return chirp.Visibility == visibilityPublic || chirp.Visibility == visibilityUnlisted
+}
+
+// visibleChirpIDs returns the subset of ids that viewer may read.
+func visibleChirpIDs(ctx context.Context, q *database.Queries, viewer uuid.NullUUID, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	visible := map[uuid.UUID]bool{}
+	if len(ids) == 0 {
+		return visible, nil
+	}
+
+	rows, err := q.GetVisibleChirpIDs(ctx, database.GetVisibleChirpIDsParams{
+		Ids:      ids,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range rows {
+		visible[id] = true
+	}
+	return visible, nil
+}
+
+// listedChirpIDs returns the subset of ids that listings may show viewer.
+func listedChirpIDs(ctx context.Context, q *database.Queries, viewer uuid.NullUUID, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	listed := map[uuid.UUID]bool{}
+	if len(ids) == 0 {
+		return listed, nil
+	}
+
+	rows, err := q.GetListedChirpIDs(ctx, database.GetListedChirpIDsParams{
+		Ids:      ids,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range rows {
+		listed[id] = true
+	}
+	return listed, nil
+}
+
+// chirpVisibleTo reports whether viewer may read chirp.
+func chirpVisibleTo(ctx context.Context, q *database.Queries, viewer uuid.NullUUID, chirp database.Chirp) (bool, error) {
+	if chirp.Visibility == visibilityPublic {
+		return true, nil
+	}
+	visible, err := visibleChirpIDs(ctx, q, viewer, []uuid.UUID{chirp.ID})
+	if err != nil {
+		return false, err
+	}
+	return visible[chirp.ID], nil
+}